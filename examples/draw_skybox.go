@@ -1,7 +1,6 @@
 package examples
 
 import (
-	"io/ioutil"
 	"math"
 
 	. "github.com/tiegz/raytracer-go/raytracer"
@@ -18,11 +17,7 @@ func RunDrawSkybox(printProgress bool, jobs int) {
 		))
 
 		getCubeSide := func(filepath string) *Pattern {
-			image, err := ioutil.ReadFile(filepath)
-			if err != nil {
-				panic(err)
-			}
-			c, err := NewCanvasFromPpm(string(image))
+			c, err := LoadCanvas(filepath)
 			if err != nil {
 				panic(err)
 			}
@@ -32,23 +27,16 @@ func RunDrawSkybox(printProgress bool, jobs int) {
 			)
 		}
 
-		cube := NewCube()
-		cube.SetTransform(cube.Transform.Compose(
-			NewUScale(1000),
-		))
 		// This cubemap can be found here: http://www.humus.name/index.php?page=Textures&ID=110
-		// Fetch the JPGs, save to tmp/, and convert to PPM with `convert XXX.jpg -compress none XXX.ppm`
-		cube.Material.Pattern = NewCubeMapPattern(
-			getCubeSide("tmp/LancellotiChapel/negx.ppm"), // l
-			getCubeSide("tmp/LancellotiChapel/posz.ppm"), // f
-			getCubeSide("tmp/LancellotiChapel/posx.ppm"), // r
-			getCubeSide("tmp/LancellotiChapel/negz.ppm"), // b
-			getCubeSide("tmp/LancellotiChapel/posy.ppm"), // u
-			getCubeSide("tmp/LancellotiChapel/negy.ppm"), // d
+		// Fetch the JPGs and save them to tmp/
+		world.Background = NewCubeMapPattern(
+			getCubeSide("tmp/LancellotiChapel/negx.jpg"), // l
+			getCubeSide("tmp/LancellotiChapel/posz.jpg"), // f
+			getCubeSide("tmp/LancellotiChapel/posx.jpg"), // r
+			getCubeSide("tmp/LancellotiChapel/negz.jpg"), // b
+			getCubeSide("tmp/LancellotiChapel/posy.jpg"), // u
+			getCubeSide("tmp/LancellotiChapel/negy.jpg"), // d
 		)
-		cube.Material.Ambient = NewColor(1.0, 1.0, 1.0)
-		cube.Material.Specular = NewColor(0, 0, 0)
-		cube.Material.Diffuse = NewColor(0, 0, 0)
 
 		sphere := NewSphere()
 		sphere.SetTransform(sphere.Transform.Compose(
@@ -62,7 +50,6 @@ func RunDrawSkybox(printProgress bool, jobs int) {
 		sphere.Material.Ambient = NewColor(0, 0, 0)
 
 		world.Objects = []*Shape{
-			cube,
 			sphere,
 		}
 		world.Lights = []*AreaLight{
@@ -1,8 +1,6 @@
 package examples
 
 import (
-	"io/ioutil"
-
 	. "github.com/tiegz/raytracer-go/raytracer"
 )
 
@@ -32,15 +30,8 @@ func RunDrawUVImage(printProgress bool, jobs int) {
 		platform.Material.Reflective = 0.1
 
 		sphere := NewSphere()
-		// Image setup:
-		// 1) wget http://planetpixelemporium.com/download/download.php?earthmap1k.jpg
-		// 2) convert tmp/earthmap1k.jpg -compress none tmp/earthmap1k.ppm
-		image, err := ioutil.ReadFile("raytracer/files/earthmap1k.ppm")
-		if err != nil {
-			panic(err)
-		}
-
-		c, err := NewCanvasFromPpm(string(image))
+		// Image setup: wget http://planetpixelemporium.com/download/download.php?earthmap1k.jpg -O tmp/earthmap1k.jpg
+		c, err := LoadCanvas("tmp/earthmap1k.jpg")
 		if err != nil {
 			panic(err)
 		}
@@ -0,0 +1,60 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// AmbientOcclusion darkens the ambient term of surfaces that are hemmed in by
+// other objects (e.g. where dice touch a table), by casting rays over the hemisphere
+// around the normal and counting how many of them hit something nearby.
+type AmbientOcclusion struct {
+	Samples     int       // rays cast per shaded point
+	MaxDistance float64   // objects farther away than this don't occlude
+	Jitter      *Sequence // each pixel starts at a different place in it (see PixelJitter)
+}
+
+func NewAmbientOcclusion(samples int, maxDistance float64) *AmbientOcclusion {
+	jitter := NewRandomSequence(2, 4096)
+	return &AmbientOcclusion{
+		Samples:     samples,
+		MaxDistance: maxDistance,
+		Jitter:      &jitter,
+	}
+}
+
+func (ao *AmbientOcclusion) String() string {
+	return fmt.Sprintf("AmbientOcclusion( Samples: %v MaxDistance: %v )", ao.Samples, ao.MaxDistance)
+}
+
+// Returns the fraction (0..1) of the hemisphere around the computation's normal that
+// isn't blocked within MaxDistance. 1 means nothing is nearby, 0 means fully occluded.
+func (ao *AmbientOcclusion) At(w *World, c *Computation) float64 {
+	if ao.Samples < 1 {
+		return 1.0
+	}
+
+	jitter := c.Jitter.Sequence(ao.Jitter)
+	unoccluded := 0
+	for i := 0; i < ao.Samples; i++ {
+		direction := cosineSampleHemisphere(c.NormalV, jitter.Next(), jitter.Next())
+		xs := w.Intersect(NewRay(c.OverPoint, direction))
+		if hit := xs.Hit(true); hit == nil || hit.Time >= ao.MaxDistance {
+			unoccluded += 1
+		}
+	}
+	return float64(unoccluded) / float64(ao.Samples)
+}
+
+// Returns a direction in the hemisphere around normal, given two numbers in [0, 1).
+// Directions near the normal are more likely, proportionally to their cosine, which
+// is how much they would contribute to the lighting anyway.
+func cosineSampleHemisphere(normal Tuple, su, sv float64) Tuple {
+	u, v := normal.OrthonormalBasis()
+	r := math.Sqrt(su)
+	phi := 2 * math.Pi * sv
+
+	return u.Multiply(r * math.Cos(phi)).
+		Add(v.Multiply(r * math.Sin(phi))).
+		Add(normal.Normalized().Multiply(math.Sqrt(math.Max(0, 1-su))))
+}
@@ -0,0 +1,130 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestAmbientOcclusionOfAnOpenSurface(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	w.Objects = []*Shape{floor}
+	ao := NewAmbientOcclusion(16, 1)
+
+	r := NewRay(NewPoint(0, 1, 0), NewVector(0, -1, 0))
+	c := NewIntersection(1, floor).PrepareComputations(r)
+
+	assertEqualFloat64(t, 1.0, ao.At(w, c))
+}
+
+func TestAmbientOcclusionOfASurfaceUnderACloseCeiling(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	ceiling := NewPlane()
+	ceiling.SetTransform(NewTranslation(0, 0.1, 0))
+	w.Objects = []*Shape{floor, ceiling}
+	r := NewRay(NewPoint(0, 0.05, 0), NewVector(0, -1, 0))
+	c := NewIntersection(0.05, floor).PrepareComputations(r)
+
+	testCases := []struct {
+		maxDistance float64
+		result      float64
+	}{
+		{100, 0.0},
+		{0.09, 1.0}, // the ceiling is at least 0.1 away
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			ao := NewAmbientOcclusion(16, tc.maxDistance)
+			assertEqualFloat64(t, tc.result, ao.At(w, c))
+		})
+	}
+}
+
+func TestAmbientOcclusionIgnoresObjectsThatDontCastShadows(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	ceiling := NewPlane()
+	ceiling.SetTransform(NewTranslation(0, 0.1, 0))
+	ceiling.Shadows = false
+	w.Objects = []*Shape{floor, ceiling}
+	r := NewRay(NewPoint(0, 0.05, 0), NewVector(0, -1, 0))
+	c := NewIntersection(0.05, floor).PrepareComputations(r)
+
+	assertEqualFloat64(t, 1.0, NewAmbientOcclusion(16, 100).At(w, c))
+}
+
+func TestShadeHitScalesTheAmbientTermByAmbientOcclusion(t *testing.T) {
+	w := NewWorld()
+	w.Lights = []*AreaLight{NewPointLight(NewPoint(0, 10, 0), Colors["White"])}
+	floor := NewPlane()
+	floor.Material.Ambient = NewColor(0.5, 0.5, 0.5)
+	ceiling := NewPlane()
+	ceiling.SetTransform(NewTranslation(0, 0.1, 0))
+	w.Objects = []*Shape{floor, ceiling}
+	r := NewRay(NewPoint(0, 0.05, 0), NewVector(0, -1, 0))
+
+	// The ceiling shadows the light, so only the ambient term is left.
+	c := NewIntersection(0.05, floor).PrepareComputations(r)
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), w.ShadeHit(c, DefaultMaximumReflections))
+
+	w.AmbientOcclusion = NewAmbientOcclusion(16, 1)
+	c = NewIntersection(0.05, floor).PrepareComputations(r)
+	assertEqualColor(t, NewColor(0, 0, 0), w.ShadeHit(c, DefaultMaximumReflections))
+}
+
+func TestRenderingAmbientOcclusionAsItsOwnImage(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	w.Objects = []*Shape{floor}
+	w.AmbientOcclusion = NewAmbientOcclusion(4, 1)
+	c := NewCamera(5, 5, math.Pi/2)
+	c.SetTransform(NewViewTransform(NewPoint(0, 1, 0), NewPoint(0, 0, 0.01), NewVector(0, 0, 1)))
+
+	image := c.RenderAmbientOcclusion(w, 1, false)
+	assertEqualColor(t, Colors["White"], image.PixelAt(2, 2))
+}
+
+func TestRenderingAmbientOcclusionInParallelIsDeterministic(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	ball := NewSphere()
+	ball.SetTransform(NewTranslation(0, 1, 0))
+	w.Objects = []*Shape{floor, ball}
+	w.AmbientOcclusion = NewAmbientOcclusion(4, 2)
+	c := NewCamera(16, 16, math.Pi/2)
+	c.SetTransform(NewViewTransform(NewPoint(0, 3, -3), NewPoint(0, 0, 0), NewVector(0, 1, 0)))
+
+	expected := c.RenderAmbientOcclusion(w, 1, false)
+	actual := c.RenderAmbientOcclusion(w, 8, false)
+	for y := 0; y < c.VSize; y++ {
+		for x := 0; x < c.HSize; x++ {
+			assertEqualColor(t, expected.PixelAt(x, y), actual.PixelAt(x, y))
+		}
+	}
+}
+
+func TestCosineSampledDirectionsAreInTheHemisphere(t *testing.T) {
+	normal := NewVector(0, 0, -1)
+	seq := NewRandomSequence(1, 64)
+	for i := 0; i < 32; i++ {
+		direction := cosineSampleHemisphere(normal, seq.Next(), seq.Next())
+		assertEqualFloat64(t, 1, direction.Magnitude())
+		assert(t, direction.Dot(normal) >= 0)
+	}
+}
+
+/////////////
+// Benchmarks
+/////////////
+
+func BenchmarkAmbientOcclusionMethodAt(b *testing.B) {
+	w := DefaultWorld()
+	ao := NewAmbientOcclusion(16, 1)
+	r := NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1))
+	c := NewIntersection(4, w.Objects[0]).PrepareComputations(r)
+	for i := 0; i < b.N; i++ {
+		ao.At(w, c)
+	}
+}
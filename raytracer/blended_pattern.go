@@ -0,0 +1,89 @@
+package raytracer
+
+import (
+	"fmt"
+)
+
+// The ways a BlendedPattern can combine its patterns.
+const (
+	BlendAverage  = "average"  // halfway between A and B
+	BlendMultiply = "multiply" // A * B, e.g. to darken a pattern with another one
+	BlendLerp     = "lerp"     // from A to B, by Weight or by the lightness of Mask
+)
+
+// BlendedPattern combines two patterns at each point, which each keep their own
+// transformation (relative to this pattern's).
+type BlendedPattern struct {
+	A      *Pattern
+	B      *Pattern
+	Mode   string   // BlendAverage, BlendMultiply or BlendLerp
+	Weight float64  // for BlendLerp: 0 is all A, 1 is all B
+	Mask   *Pattern // for BlendLerp: if set, used instead of Weight (black is all A, white is all B)
+}
+
+func NewBlendedPattern(a, b *Pattern, mode string) *Pattern {
+	return NewPattern(BlendedPattern{A: a, B: b, Mode: mode, Weight: 0.5})
+}
+
+// Returns a pattern that's weight of the way from a to b.
+func NewLerpedPattern(a, b *Pattern, weight float64) *Pattern {
+	return NewPattern(BlendedPattern{A: a, B: b, Mode: BlendLerp, Weight: weight})
+}
+
+// Returns a pattern that's a where mask is black, b where it's white, and in between otherwise.
+func NewMaskedPattern(a, b, mask *Pattern) *Pattern {
+	return NewPattern(BlendedPattern{A: a, B: b, Mode: BlendLerp, Mask: mask})
+}
+
+func (p BlendedPattern) String() string {
+	return fmt.Sprintf("BlendedPattern( A: %v B: %v Mode: %v Weight: %v Mask: %v )", p.A, p.B, p.Mode, p.Weight, p.Mask)
+}
+
+func (p BlendedPattern) blend(a, b Color, weight func() float64) Color {
+	switch p.Mode {
+	case BlendMultiply:
+		return a.MultiplyColor(b)
+	case BlendLerp:
+		return a.Add(b.Subtract(a).Multiply(weight()))
+	default:
+		return a.Add(b).Divide(2)
+	}
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p BlendedPattern) LocalPatternAt(point Tuple) Color {
+	return p.blend(p.A.PatternAt(point), p.B.PatternAt(point), func() float64 {
+		if p.Mask != nil {
+			return p.Mask.PatternAt(point).Luminance()
+		}
+		return p.Weight
+	})
+}
+
+func (p BlendedPattern) LocalUVPatternAt(u, v float64) Color {
+	return p.blend(p.A.UVPatternAt(u, v), p.B.UVPatternAt(u, v), func() float64 {
+		if p.Mask != nil {
+			return p.Mask.UVPatternAt(u, v).Luminance()
+		}
+		return p.Weight
+	})
+}
+
+func (p BlendedPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(BlendedPattern)
+	if p.Mode != p2Pattern.Mode || p.Weight != p2Pattern.Weight {
+		return false
+	} else if !p.A.IsEqualTo(p2Pattern.A) || !p.B.IsEqualTo(p2Pattern.B) {
+		return false
+	}
+	return patternsAreEqual(p.Mask, p2Pattern.Mask)
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p BlendedPattern) localType() string {
+	return "BlendedPattern"
+}
@@ -38,6 +38,9 @@ func (b *BoundingBox) AddPoints(points ...Tuple) {
 
 func (b *BoundingBox) AddBoundingBoxes(boundingBoxes ...BoundingBox) {
 	for _, bb := range boundingBoxes {
+		if bb.MinPoint.X > bb.MaxPoint.X {
+			continue // an empty box (e.g. of an empty group) has nothing to add
+		}
 		b.AddPoints(bb.MinPoint)
 		b.AddPoints(bb.MaxPoint)
 	}
@@ -86,6 +89,12 @@ func (b BoundingBox) ContainsBox(b2 BoundingBox) bool {
 // translation and rotation, we need to get all the points from the
 // box, transform each by the matrix, and then find the new min/max.
 func (b BoundingBox) Transform(m Matrix) BoundingBox {
+	// An empty box (e.g. of an empty group) stays empty. NB transforming its infinite
+	// corners would make them NaN, and then so would any box it's added to.
+	if b.MinPoint.X > b.MaxPoint.X {
+		return NullBoundingBox()
+	}
+
 	p1 := b.MinPoint
 	p2 := NewPoint(b.MinPoint.X, b.MinPoint.Y, b.MaxPoint.Z)
 	p3 := NewPoint(b.MinPoint.X, b.MaxPoint.Y, b.MinPoint.Z)
@@ -141,6 +150,16 @@ func (b BoundingBox) checkAxis(origin float64, direction float64, min float64, m
 	}
 }
 
+// Returns the area of the box's sides, e.g. for estimating how likely rays are to hit it.
+// Empty boxes (e.g. NullBoundingBox) have none.
+func (b BoundingBox) SurfaceArea() float64 {
+	size := b.MaxPoint.Subtract(b.MinPoint)
+	if size.X < 0 || size.Y < 0 || size.Z < 0 {
+		return 0
+	}
+	return 2 * (size.X*size.Y + size.Y*size.Z + size.Z*size.X)
+}
+
 func (b BoundingBox) String() string {
 	return fmt.Sprintf("BoundingBox( MinPoint: %v MaxPoint: %v )", b.MinPoint, b.MaxPoint)
 }
@@ -99,6 +99,15 @@ func TestTransformingABoundingBox(t *testing.T) {
 	assertEqualTuple(t, NewPoint(1.41421, 1.70711, 1.70711), b2.MaxPoint)
 }
 
+func TestTransformingAnEmptyBoundingBox(t *testing.T) {
+	b := NullBoundingBox().Transform(NewTranslation(1, 2, 3))
+	assertEqualBoundingBox(t, NullBoundingBox(), b)
+
+	g := NewGroup()
+	g.AddChildren(NewSphere(), NewGroup())
+	assertEqualBoundingBox(t, NewBoundingBox(NewPoint(-1, -1, -1), NewPoint(1, 1, 1)), g.Bounds())
+}
+
 func TestIntersectingARayWithABoundingBoxAtTheOrigin(t *testing.T) {
 	b := NewBoundingBox(NewPoint(-1, -1, -1), NewPoint(1, 1, 1))
 
@@ -218,3 +227,8 @@ func TestPartitioningAGroupsChildren(t *testing.T) {
 	assertEqualShape(t, *s1, *left[0])
 	assertEqualShape(t, *s2, *right[0])
 }
+
+func TestTheSurfaceAreaOfABoundingBox(t *testing.T) {
+	assertEqualFloat64(t, 2*(2*3+3*4+4*2), NewBoundingBox(NewPoint(-1, 0, 1), NewPoint(1, 3, 5)).SurfaceArea())
+	assertEqualFloat64(t, 0, NullBoundingBox().SurfaceArea())
+}
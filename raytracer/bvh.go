@@ -0,0 +1,300 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// The ways that DivideWithOptions can split a group's children into subgroups.
+const (
+	MidpointSplit = "midpoint" // at the middle of the longest side of the group's bounds, leaving children that straddle it (the same as Divide)
+	SAHSplit      = "sah"      // wherever the surface area heuristic estimates is cheapest to intersect (see BVHStats.SAHCost)
+)
+
+// How many places along each axis SAHSplit tries splitting at, by default.
+const DefaultSAHBins = 16
+
+// The costs that the surface area heuristic weighs, relative to each other.
+const (
+	sahTraversalCost    = 1.0 // intersecting a group's bounding box
+	sahIntersectionCost = 1.0 // intersecting one of a group's children (other than a group)
+)
+
+// Options for dividing groups into a BVH (bounding volume hierarchy), see DivideWithOptions.
+type BVHOptions struct {
+	Threshold int    // only groups with at least this many children are divided (see Divide)
+	Split     string // MidpointSplit (or empty) or SAHSplit
+	Bins      int    // for SAHSplit: how many places along each axis to try splitting at (DefaultSAHBins if 0)
+}
+
+// Same as Divide, but with options, e.g. to split groups with the surface area heuristic:
+// it sorts the children into Bins along each axis by the centers of their bounds, and
+// splits between the bins where the area of the two subgroups, weighted by how many
+// children each has, is smallest. Unlike MidpointSplit, every child ends up on one side
+// (except infinite ones, like planes), and groups aren't split when intersecting all of
+// their children is estimated to be cheaper than intersecting two subgroups.
+func (s *Shape) DivideWithOptions(options BVHOptions) {
+	if options.Split != SAHSplit {
+		s.Divide(options.Threshold)
+		return
+	}
+
+	switch localShape := s.LocalShape.(type) {
+	case Group:
+		if len(localShape.Children) >= maxInt(options.Threshold, 2) {
+			bins := options.Bins
+			if bins <= 0 {
+				bins = DefaultSAHBins
+			}
+			l, r := s.partitionChildrenSAH(bins)
+			if len(l) > 0 && len(r) > 0 {
+				s.MakeSubGroup(l...)
+				s.MakeSubGroup(r...)
+			}
+		}
+		for _, child := range s.LocalShape.(Group).Children {
+			child.DivideWithOptions(options)
+		}
+	case Csg:
+		localShape.Left.DivideWithOptions(options)
+		localShape.Right.DivideWithOptions(options)
+	default:
+		// no-op
+	}
+}
+
+// Like PartitionChildren, but splits the children where the surface area heuristic
+// estimates is cheapest, or not at all if not splitting them is cheaper.
+func (s *Shape) partitionChildrenSAH(bins int) ([]*Shape, []*Shape) {
+	g := s.LocalShape.(Group)
+
+	// Only children with finite bounds can be sorted into bins, so the rest stay in the group.
+	boxes, centers := []BoundingBox{}, []Tuple{}
+	finite := make([]bool, len(g.Children))
+	for idx, child := range g.Children {
+		box := child.ParentSpaceBounds()
+		if !isFiniteTuple(box.MinPoint) || !isFiniteTuple(box.MaxPoint) {
+			continue
+		}
+		finite[idx] = true
+		boxes = append(boxes, box)
+		centers = append(centers, box.MinPoint.Add(box.MaxPoint).Divide(2))
+	}
+	split := findSAHSplit(boxes, centers, bins)
+	if split.axis < 0 {
+		return nil, nil
+	}
+
+	l, r := []*Shape{}, []*Shape{}
+	newChildren := []*Shape{}
+	finiteIdx := 0
+	for idx, child := range g.Children {
+		if !finite[idx] {
+			newChildren = append(newChildren, child)
+			continue
+		}
+		if split.isLeft(centers[finiteIdx]) {
+			l = append(l, child)
+		} else {
+			r = append(r, child)
+		}
+		finiteIdx++
+	}
+	g.Children = newChildren
+	s.LocalShape = g
+	return l, r
+}
+
+// Where to split things (e.g. a group's children, or a Mesh's triangles) into two, found
+// by findSAHSplit: things whose centers are in the bins before bin along axis go on the
+// left, and the rest on the right. If axis is -1, they aren't worth splitting.
+type sahSplit struct {
+	axis, bin    int
+	bins         int
+	centerBounds BoundingBox
+}
+
+// Returns where the surface area heuristic estimates is cheapest to split things with the
+// given bounding boxes, by sorting them into bins along each axis by their centers, and
+// trying to split them between each of the bins (see DivideWithOptions).
+func findSAHSplit(boxes []BoundingBox, centers []Tuple, bins int) sahSplit {
+	split := sahSplit{axis: -1, bins: bins, centerBounds: NullBoundingBox()}
+	if len(boxes) < 2 {
+		return split
+	}
+	bounds := NullBoundingBox()
+	for idx, box := range boxes {
+		bounds.AddBoundingBoxes(box)
+		split.centerBounds.AddPoints(centers[idx])
+	}
+
+	// NB leaving them as they are is the cost to beat.
+	bestCost := sahIntersectionCost * float64(len(boxes)) * bounds.SurfaceArea()
+	for axis := 0; axis < 3; axis++ {
+		if tupleAxis(split.centerBounds.MaxPoint, axis)-tupleAxis(split.centerBounds.MinPoint, axis) < EPSILON {
+			continue // all of the centers are in one bin
+		}
+		counts := make([]int, bins)
+		binBounds := make([]BoundingBox, bins)
+		for bin := range binBounds {
+			binBounds[bin] = NullBoundingBox()
+		}
+		for idx, box := range boxes {
+			bin := split.binOf(centers[idx], axis)
+			counts[bin] += 1
+			binBounds[bin].AddBoundingBoxes(box)
+		}
+
+		// The area and count of the ones to the right of each split, i.e. in bins >= it.
+		rightAreas := make([]float64, bins)
+		rightCounts := make([]int, bins)
+		right := NullBoundingBox()
+		count := 0
+		for bin := bins - 1; bin > 0; bin-- {
+			right.AddBoundingBoxes(binBounds[bin])
+			count += counts[bin]
+			rightAreas[bin], rightCounts[bin] = right.SurfaceArea(), count
+		}
+
+		left := NullBoundingBox()
+		count = 0
+		for bin := 1; bin < bins; bin++ { // i.e. splitting between bin-1 and bin
+			left.AddBoundingBoxes(binBounds[bin-1])
+			count += counts[bin-1]
+			if count == 0 || rightCounts[bin] == 0 {
+				continue
+			}
+			cost := sahTraversalCost*bounds.SurfaceArea() +
+				sahIntersectionCost*(left.SurfaceArea()*float64(count)+rightAreas[bin]*float64(rightCounts[bin]))
+			if cost < bestCost {
+				bestCost, split.axis, split.bin = cost, axis, bin
+			}
+		}
+	}
+	return split
+}
+
+// Returns the bin that a center is in along an axis.
+func (split sahSplit) binOf(center Tuple, axis int) int {
+	min, max := tupleAxis(split.centerBounds.MinPoint, axis), tupleAxis(split.centerBounds.MaxPoint, axis)
+	return minInt(split.bins-1, int(float64(split.bins)*(tupleAxis(center, axis)-min)/(max-min)))
+}
+
+// Does a center go on the left side of the split?
+func (split sahSplit) isLeft(center Tuple) bool {
+	return split.binOf(center, split.axis) < split.bin
+}
+
+func isFiniteTuple(t Tuple) bool {
+	for _, f := range []float64{t.X, t.Y, t.Z} {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return false
+		}
+	}
+	return true
+}
+
+// BVHStats describes the BVH (bounding volume hierarchy) of a group, e.g. to compare ways
+// of dividing it (see DivideWithOptions), or of a Mesh, whose nodes count as groups.
+type BVHStats struct {
+	Groups         int     // how many groups there are, including the group itself
+	Leaves         int     // groups without groups in them
+	Depth          int     // how many groups deep the deepest leaf is (1 for just the group itself)
+	MinLeafSize    int     // the fewest children a leaf has
+	MaxLeafSize    int     // the most children a leaf has
+	MeanLeafSize   float64 // how many children leaves have on average
+	InteriorShapes int     // how many children (other than groups) are in groups that aren't leaves, e.g. ones that straddle a MidpointSplit
+	SAHCost        float64 // the cost of intersecting a ray with the group, estimated with the surface area heuristic (lower is better)
+}
+
+// Returns stats about the group's BVH. The SAHCost adds up the cost of each group's bounding
+// box and children (except for groups), weighted by how likely a ray that hits the group is
+// to hit it, i.e. by its surface area relative to the group's.
+func (s *Shape) BVHStats() BVHStats {
+	if mesh, isMesh := s.LocalShape.(*Mesh); isMesh {
+		return mesh.bvhStats()
+	} else if _, isGroup := s.LocalShape.(Group); !isGroup {
+		return BVHStats{}
+	}
+	stats := BVHStats{MinLeafSize: math.MaxInt}
+
+	// The area of each group (in the group's space) and how many children it has (except for
+	// groups), to weight them by once the group's own area is known.
+	areas, shapeCounts := []float64{}, []int{}
+	leafSizes := 0
+
+	// Returns the bounds of g's children in s's space, and whether any of them are infinite.
+	// NB this skips empty children (e.g. empty groups), which Group's bounds don't.
+	var visit func(g *Shape, transform Matrix, depth int) (BoundingBox, bool)
+	visit = func(g *Shape, transform Matrix, depth int) (BoundingBox, bool) {
+		stats.Groups += 1
+		stats.Depth = maxInt(stats.Depth, depth)
+
+		bounds, infinite := NullBoundingBox(), false
+		shapes, groups := 0, 0
+		for _, child := range g.LocalShape.(Group).Children {
+			var b BoundingBox
+			if _, isGroup := child.LocalShape.(Group); isGroup {
+				groups += 1
+				childInfinite := false
+				b, childInfinite = visit(child, transform.Multiply(child.Transform), depth+1)
+				infinite = infinite || childInfinite
+			} else {
+				shapes += 1
+				if b = child.Bounds(); b.MinPoint.X <= b.MaxPoint.X {
+					b = b.Transform(transform.Multiply(child.Transform))
+				}
+			}
+			if b.MinPoint.X > b.MaxPoint.X {
+				continue // empty
+			} else if !isFiniteTuple(b.MinPoint) || !isFiniteTuple(b.MaxPoint) {
+				infinite = true
+				continue
+			}
+			bounds.AddBoundingBoxes(b)
+		}
+		if infinite {
+			areas = append(areas, math.Inf(1))
+		} else {
+			areas = append(areas, bounds.SurfaceArea())
+		}
+		shapeCounts = append(shapeCounts, shapes)
+
+		if groups > 0 {
+			stats.InteriorShapes += shapes
+		} else {
+			stats.Leaves += 1
+			stats.MinLeafSize = minInt(stats.MinLeafSize, shapes)
+			stats.MaxLeafSize = maxInt(stats.MaxLeafSize, shapes)
+			leafSizes += shapes
+		}
+		return bounds, infinite
+	}
+	visit(s, IdentityMatrix(), 1)
+
+	// NB if the group is infinite (e.g. it has a plane) or flat, its groups are all weighted the same.
+	rootArea := areas[len(areas)-1]
+	for idx, area := range areas {
+		weight := 1.0
+		if rootArea > 0 && !math.IsInf(rootArea, 1) {
+			weight = area / rootArea
+		}
+		stats.SAHCost += weight * (sahTraversalCost + sahIntersectionCost*float64(shapeCounts[idx]))
+	}
+	stats.MeanLeafSize = float64(leafSizes) / float64(stats.Leaves)
+	return stats
+}
+
+func (stats BVHStats) String() string {
+	return fmt.Sprintf(
+		"BVHStats( Groups: %d Leaves: %d Depth: %d LeafSizes: %d-%d (mean %.2f) InteriorShapes: %d SAHCost: %.2f )",
+		stats.Groups,
+		stats.Leaves,
+		stats.Depth,
+		stats.MinLeafSize,
+		stats.MaxLeafSize,
+		stats.MeanLeafSize,
+		stats.InteriorShapes,
+		stats.SAHCost,
+	)
+}
@@ -0,0 +1,203 @@
+package raytracer
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestDividingAGroupWithTheSurfaceAreaHeuristic(t *testing.T) {
+	s1 := NewSphere()
+	s1.SetTransform(NewTranslation(-10, 0, 0))
+	s2 := NewSphere()
+	s2.SetTransform(NewTranslation(-8, 0, 0))
+	s3 := NewSphere()
+	s3.SetTransform(NewTranslation(8, 0, 0))
+	s4 := NewSphere()
+	s4.SetTransform(NewTranslation(10, 0, 0))
+	p := NewPlane()
+	g := NewGroup()
+	g.AddChildren(s1, s2, s3, s4, p)
+	g.DivideWithOptions(BVHOptions{Threshold: 3, Split: SAHSplit})
+	group := g.LocalShape.(Group)
+
+	assertEqualInt(t, 3, len(group.Children))
+	assertEqualShape(t, *p, *group.Children[0])
+	assertEqualGroup(t, []*Shape{s1, s2}, *group.Children[1])
+	assertEqualGroup(t, []*Shape{s3, s4}, *group.Children[2])
+}
+
+func TestDividingAGroupWithTheSurfaceAreaHeuristicWhenSplittingIsntCheaper(t *testing.T) {
+	s1 := NewSphere()
+	s2 := NewSphere()
+	s2.SetTransform(NewTranslation(0.1, 0, 0))
+	g := NewGroup()
+	g.AddChildren(s1, s2)
+	g.DivideWithOptions(BVHOptions{Split: SAHSplit})
+
+	assertEqualGroup(t, []*Shape{s1, s2}, *g)
+}
+
+func TestDividingAGroupWithoutASplitIsTheSameAsDivide(t *testing.T) {
+	build := func() *Shape {
+		g := NewGroup()
+		for x := 0; x < 8; x++ {
+			s := NewSphere()
+			s.SetTransform(NewTranslation(float64(x)*3, 0, 0))
+			g.AddChildren(s)
+		}
+		return g
+	}
+	expected, actual := build(), build()
+	expected.Divide(2)
+	actual.DivideWithOptions(BVHOptions{Threshold: 2})
+
+	assertEqualString(t, expected.BVHStats().String(), actual.BVHStats().String())
+}
+
+func TestTheBVHStatsOfAGroup(t *testing.T) {
+	s1 := NewSphere()
+	s1.SetTransform(NewTranslation(-2, 0, 0))
+	s2 := NewSphere()
+	s2.SetTransform(NewTranslation(2, 0, 0))
+	s3 := NewSphere()
+	sg := NewGroup()
+	sg.AddChildren(s1, s2)
+	g := NewGroup()
+	g.AddChildren(sg, s3)
+	stats := g.BVHStats()
+
+	assertEqualInt(t, 2, stats.Groups)
+	assertEqualInt(t, 1, stats.Leaves)
+	assertEqualInt(t, 2, stats.Depth)
+	assertEqualInt(t, 2, stats.MinLeafSize)
+	assertEqualInt(t, 2, stats.MaxLeafSize)
+	assertEqualFloat64(t, 2, stats.MeanLeafSize)
+	assertEqualInt(t, 1, stats.InteriorShapes)
+	// g: 1 + 1 (s3), and sg, which has the same bounds: 1 + 2 (s1 and s2)
+	assertEqualFloat64(t, 5, stats.SAHCost)
+}
+
+func TestTheBVHStatsOfAShapeThatIsntAGroup(t *testing.T) {
+	assertEqualInt(t, 0, NewSphere().BVHStats().Groups)
+}
+
+func TestDividingATeapotWithTheSurfaceAreaHeuristic(t *testing.T) {
+	load := func(options BVHOptions) *Shape {
+		f, err := os.Open("files/utah_teapot_hires.obj")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		of, err := ParseObj(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := of.ToGroup()
+		g.DivideWithOptions(options)
+		return g
+	}
+	midpoint, sah := load(BVHOptions{Threshold: 4}), load(BVHOptions{Threshold: 4, Split: SAHSplit})
+	midpointStats, sahStats := midpoint.BVHStats(), sah.BVHStats()
+
+	assertEqualInt(t, 0, sahStats.InteriorShapes)
+	if sahStats.SAHCost >= midpointStats.SAHCost {
+		t.Errorf("Expected the SAH split to be cheaper than the midpoint split, but got\n%v\n%v", sahStats, midpointStats)
+	}
+	for idx, x := range []float64{-3, -1, 0, 1, 3} {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			r := NewRay(NewPoint(x, 1.5, -10), NewVector(0, 0, 1))
+			expected, actual := midpoint.Intersect(r), sah.Intersect(r)
+			assertEqualInt(t, len(expected), len(actual))
+			for idx := range expected {
+				if idx >= len(actual) {
+					break
+				}
+				assertEqualFloat64(t, expected[idx].Time, actual[idx].Time)
+			}
+		})
+	}
+}
+
+func TestBuildingATeapotMeshWithTheSurfaceAreaHeuristic(t *testing.T) {
+	load := func(options BVHOptions) *Shape {
+		f, err := os.Open("files/utah_teapot_hires.obj")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		of, err := ParseObjWithOptions(f, ObjOptions{Meshes: true, BVH: options})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findMesh(of.ToGroup())
+	}
+	midpoint, sah := load(BVHOptions{}), load(BVHOptions{Split: SAHSplit})
+	midpointStats, sahStats := midpoint.BVHStats(), sah.BVHStats()
+
+	if sahStats.SAHCost >= midpointStats.SAHCost {
+		t.Errorf("Expected the SAH split to be cheaper than the midpoint split, but got\n%v\n%v", sahStats, midpointStats)
+	}
+	for idx, x := range []float64{-3, -1, 0, 1, 3} {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			r := NewRay(NewPoint(x, -10, 1.5), NewVector(0, 1, 0))
+			expected, actual := midpoint.Intersect(r), sah.Intersect(r)
+			assertEqualInt(t, len(expected), len(actual))
+			for idx := range expected {
+				if idx >= len(actual) {
+					break
+				}
+				assertEqualFloat64(t, expected[idx].Time, actual[idx].Time)
+			}
+		})
+	}
+}
+
+// Returns the first Mesh in a group (or its subgroups), or nil if there isn't one.
+func findMesh(s *Shape) *Shape {
+	if _, isMesh := s.LocalShape.(*Mesh); isMesh {
+		return s
+	} else if g, isGroup := s.LocalShape.(Group); isGroup {
+		for _, child := range g.Children {
+			if mesh := findMesh(child); mesh != nil {
+				return mesh
+			}
+		}
+	}
+	return nil
+}
+
+func TestTheBVHStatsOfAMesh(t *testing.T) {
+	stats := newTestSquareMesh().BVHStats()
+
+	assertEqualInt(t, 1, stats.Groups)
+	assertEqualInt(t, 1, stats.Leaves)
+	assertEqualInt(t, 1, stats.Depth)
+	assertEqualInt(t, 2, stats.MinLeafSize)
+	assertEqualInt(t, 2, stats.MaxLeafSize)
+	assertEqualFloat64(t, sahTraversalCost+2*sahIntersectionCost, stats.SAHCost)
+	assertEqualInt(t, 0, NewMesh([]Tuple{}, [][3]int{}).BVHStats().Groups)
+}
+
+/////////////
+// Benchmarks
+/////////////
+
+func BenchmarkDivideWithOptionsMethod(b *testing.B) {
+	data, err := os.ReadFile("files/utah_teapot_hires.obj")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, split := range []string{MidpointSplit, SAHSplit} {
+		b.Run(split, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				of := ParseObjFile(string(data))
+				g := of.ToGroup()
+				b.StartTimer()
+				g.DivideWithOptions(BVHOptions{Threshold: 4, Split: split})
+			}
+		})
+	}
+}
@@ -78,7 +78,9 @@ func (c *Camera) RayForPixel(pixelX, pixelY int) *Ray {
 	direction := pixel.Subtract(origin)
 	direction = direction.Normalized()
 
-	return NewRay(origin, direction)
+	r := NewRay(origin, direction)
+	r.Spread = c.PixelSize() // the pixel is this wide, 1 unit away from the camera
+	return r
 }
 
 // Renders the world onto a canvas with the given camera, and returns the canvas.
@@ -87,6 +89,19 @@ func (c *Camera) RayForPixel(pixelX, pixelY int) *Ray {
 //
 // The number of pixels to render at a time can be controlled with the jobs argument.
 func (c *Camera) Render(w *World, jobs int, printProgress bool) Canvas {
+	return c.render(jobs, printProgress, func(r *Ray) Color {
+		return w.ColorAt(r, DefaultMaximumReflections)
+	})
+}
+
+// Renders the world's ambient occlusion (see World.AmbientOcclusion) onto a separate
+// grayscale canvas, e.g. for compositing.
+func (c *Camera) RenderAmbientOcclusion(w *World, jobs int, printProgress bool) Canvas {
+	return c.render(jobs, printProgress, w.AmbientOcclusionAt)
+}
+
+// Renders each pixel with the color that colorAt() returns for its ray.
+func (c *Camera) render(jobs int, printProgress bool, colorAt func(*Ray) Color) Canvas {
 	canvas := NewCanvas(c.HSize, c.VSize)
 
 	count := c.HSize * c.VSize
@@ -101,7 +116,8 @@ func (c *Camera) Render(w *World, jobs int, printProgress bool) Canvas {
 			i += 1
 			go func(x, y, i, count int, wg *sync.WaitGroup) {
 				r := c.RayForPixel(x, y)
-				color := w.ColorAt(r, DefaultMaximumReflections)
+				r.Jitter = NewPixelJitter(y*c.HSize + x)
+				color := colorAt(r)
 				canvas.WritePixel(x, y, color)
 				if printProgress {
 					printMutex.Lock()
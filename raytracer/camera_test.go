@@ -54,6 +54,14 @@ func TestConstructingARayWhenCameraIsTransformed(t *testing.T) {
 	assertEqualTuple(t, NewVector(math.Sqrt(2)/2, 0, -(math.Sqrt(2)/2)), r.Direction)
 }
 
+func TestRaysForPixelsSpreadByThePixelSize(t *testing.T) {
+	c := NewCamera(200, 125, math.Pi/2)
+	r := c.RayForPixel(100, 50)
+
+	assertEqualFloat64(t, 0.01, r.Spread)
+	assertEqualFloat64(t, 0, r.Footprint)
+}
+
 func TestRenderingWorldWithCamera(t *testing.T) {
 	w := DefaultWorld()
 	c := NewCamera(11, 11, math.Pi/2)
@@ -82,6 +90,27 @@ func TestRenderingWorldWithCameraInParallel(t *testing.T) {
 	assertEqualColor(t, expected, actual)
 }
 
+func TestRenderingWorldWithGlossyReflectionsInParallelIsDeterministic(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewVerticalGradientPattern(Colors["Black"], Colors["White"])
+	floor := NewPlane()
+	floor.Material.Reflective = 1
+	floor.Material.Roughness = 0.3
+	w.Objects = []*Shape{floor}
+	c := NewCamera(16, 16, math.Pi/2)
+	c.SetTransform(NewViewTransform(NewPoint(0, 1, -5), NewPoint(0, 0, 0), NewVector(0, 1, 0)))
+
+	expected := c.Render(w, 1, false)
+	for jobs := 2; jobs <= 8; jobs *= 2 {
+		actual := c.Render(w, jobs, false)
+		for y := 0; y < c.VSize; y++ {
+			for x := 0; x < c.HSize; x++ {
+				assertEqualColor(t, expected.PixelAt(x, y), actual.PixelAt(x, y))
+			}
+		}
+	}
+}
+
 /////////////
 // Benchmarks
 /////////////
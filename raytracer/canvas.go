@@ -8,10 +8,14 @@ import (
 	"image/gif"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 type Canvas struct {
@@ -35,7 +39,13 @@ func NewCanvas(w, h int, defaultColor ...Color) Canvas {
 	return c
 }
 
+// Returns a canvas from a PPM image, either plain (P3) or binary (P6). The colors are
+// left as they are in the file (see SRGBToLinear for converting them).
 func NewCanvasFromPpm(ppm string) (Canvas, error) {
+	if strings.HasPrefix(ppm, "P6") {
+		return newCanvasFromBinaryPpm([]byte(ppm))
+	}
+
 	var colorScale float64
 	var w, h int
 	var err error
@@ -93,6 +103,223 @@ func NewCanvasFromPpm(ppm string) (Canvas, error) {
 	return c, nil
 }
 
+// Returns a canvas from a binary (P6) PPM image, where each sample is one byte (or
+// two, big-endian, when the max value is over 255).
+func newCanvasFromBinaryPpm(data []byte) (Canvas, error) {
+	var c Canvas
+
+	// The header is 4 fields (magic number, width, height, max value), separated by
+	// whitespace and comments, and followed by a single whitespace character.
+	fields := []string{}
+	i := 0
+	for len(fields) < 4 && i < len(data) {
+		if data[i] == '#' {
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		} else if unicode.IsSpace(rune(data[i])) {
+			i++
+		} else {
+			start := i
+			for i < len(data) && !unicode.IsSpace(rune(data[i])) {
+				i++
+			}
+			fields = append(fields, string(data[start:i]))
+		}
+	}
+	if len(fields) < 4 {
+		return c, fmt.Errorf("raytracer.NewCanvasFromPpm: invalid ppm file, the header is incomplete.")
+	}
+	i++
+
+	var w, h, maxValue int
+	var err error
+	if w, err = strconv.Atoi(fields[1]); err != nil {
+		return c, err
+	}
+	if h, err = strconv.Atoi(fields[2]); err != nil {
+		return c, err
+	}
+	if maxValue, err = strconv.Atoi(fields[3]); err != nil {
+		return c, err
+	}
+
+	bytesPerSample := 1
+	if maxValue > 255 {
+		bytesPerSample = 2
+	}
+	if len(data)-i < w*h*3*bytesPerSample {
+		return c, fmt.Errorf("raytracer.NewCanvasFromPpm: invalid ppm file, expected %d bytes of pixels but found %d.", w*h*3*bytesPerSample, len(data)-i)
+	}
+
+	c = Canvas{w, h, float64(maxValue), make([]Color, h*w)}
+	sample := func(idx int) float64 {
+		offset := i + idx*bytesPerSample
+		if bytesPerSample == 2 {
+			return float64(int(data[offset])<<8|int(data[offset+1])) / c.ColorScale
+		}
+		return float64(data[offset]) / c.ColorScale
+	}
+	for p := 0; p < w*h; p++ {
+		c.Pixels[p] = NewColor(sample(p*3), sample(p*3+1), sample(p*3+2))
+	}
+
+	return c, nil
+}
+
+// Returns a canvas from an image from Go's std lib (e.g. decoded with image.Decode()).
+// The colors are left as they are in the image (see SRGBToLinear for converting them).
+func NewCanvasFromImage(img image.Image) Canvas {
+	bounds := img.Bounds()
+	c := NewCanvas(bounds.Dx(), bounds.Dy())
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			color := NewColor(float64(r), float64(g), float64(b))
+			if a > 0 {
+				color = color.Divide(float64(a)) // un-premultiply alpha
+			}
+			c.WritePixel(x, y, color)
+		}
+	}
+	return c
+}
+
+// Returns a canvas from an image file, based on its extension: PNG, JPEG, GIF, PPM (P3 or P6)
+// or Radiance HDR. The colors are left as they are in the file, so that a render that uses
+// it as a texture and is saved as it is (e.g. with SavePNG) shows the same colors as the file.
+// For textures to shade correctly, convert them to linear (see SRGBToLinear), and the
+// render back to sRGB before saving it (see LinearToSRGB). HDR images are already linear.
+func LoadCanvas(path string) (Canvas, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Canvas{}, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdr":
+		return NewCanvasFromHdr(data)
+	case ".ppm", ".pnm":
+		return NewCanvasFromPpm(string(data))
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Canvas{}, fmt.Errorf("raytracer.LoadCanvas: %s: %w", path, err)
+		}
+		return NewCanvasFromImage(img), nil
+	}
+}
+
+// Returns a canvas from a Radiance HDR (.hdr, RGBE) image. Unlike 8-bit images, its
+// colors aren't limited to 1.0, which makes it suitable for lighting (see EnvironmentLight).
+func NewCanvasFromHdr(data []byte) (Canvas, error) {
+	var c Canvas
+	var w, h int
+	reader := bufio.NewReader(bytes.NewReader(data))
+
+	// Read the header, which ends with an empty line.
+	magicNumber, err := reader.ReadString('\n')
+	if err != nil {
+		return c, err
+	}
+	if !strings.HasPrefix(magicNumber, "#?") {
+		return c, fmt.Errorf("raytracer.NewCanvasFromHdr: invalid hdr file, started with %q instead of #?.", strings.TrimSpace(magicNumber))
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return c, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		} else if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT=32-bit_rle_rgbe" {
+			return c, fmt.Errorf("raytracer.NewCanvasFromHdr: unsupported format %s.", line)
+		}
+	}
+
+	// Set dimensions (only the standard top-to-bottom, left-to-right orientation is supported)
+	dimensions, err := reader.ReadString('\n')
+	if err != nil {
+		return c, err
+	}
+	if _, err := fmt.Sscanf(dimensions, "-Y %d +X %d", &h, &w); err != nil {
+		return c, fmt.Errorf("raytracer.NewCanvasFromHdr: unsupported resolution %q.", strings.TrimSpace(dimensions))
+	}
+
+	c = NewCanvas(w, h)
+	scanline := make([]byte, w*4) // stored as rgbergbe... after decoding
+	for y := 0; y < h; y++ {
+		if err := readHdrScanline(reader, scanline, w); err != nil {
+			return c, err
+		}
+		for x := 0; x < w; x++ {
+			rgbe := scanline[x*4 : x*4+4]
+			if rgbe[3] == 0 {
+				continue
+			}
+			f := math.Ldexp(1, int(rgbe[3])-(128+8))
+			c.WritePixel(x, y, NewColor(float64(rgbe[0])*f, float64(rgbe[1])*f, float64(rgbe[2])*f))
+		}
+	}
+
+	return c, nil
+}
+
+// Reads one scanline of an HDR image, which is either flat or run-length encoded
+// one component at a time.
+func readHdrScanline(reader *bufio.Reader, scanline []byte, w int) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return err
+	}
+
+	// Flat scanline: the header was actually the first pixel.
+	if w < 8 || w > 0x7fff || header[0] != 2 || header[1] != 2 || header[2]&0x80 != 0 {
+		copy(scanline, header)
+		_, err := io.ReadFull(reader, scanline[4:])
+		return err
+	}
+
+	if (int(header[2])<<8)|int(header[3]) != w {
+		return fmt.Errorf("raytracer.NewCanvasFromHdr: invalid scanline width.")
+	}
+	for component := 0; component < 4; component++ {
+		for x := 0; x < w; {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return err
+			}
+			if count > 128 { // a run of the same value
+				count -= 128
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+				if x+int(count) > w {
+					return fmt.Errorf("raytracer.NewCanvasFromHdr: invalid run length.")
+				}
+				for i := 0; i < int(count); i++ {
+					scanline[(x+i)*4+component] = value
+				}
+			} else { // a run of different values
+				if count == 0 || x+int(count) > w {
+					return fmt.Errorf("raytracer.NewCanvasFromHdr: invalid run length.")
+				}
+				for i := 0; i < int(count); i++ {
+					value, err := reader.ReadByte()
+					if err != nil {
+						return err
+					}
+					scanline[(x+i)*4+component] = value
+				}
+			}
+			x += int(count)
+		}
+	}
+	return nil
+}
+
 func (c Canvas) String() string {
 	return fmt.Sprintf("Canvas(\nWidth: %v\nHeight: %v\nColorScale: %v\n)", c.Width, c.Height, c.ColorScale)
 }
@@ -127,6 +354,22 @@ func (c *Canvas) PixelAt(x, y int) Color {
 	return c.Pixels[index]
 }
 
+// Converts the colors from sRGB (i.e. how most images are encoded) to linear values, which
+// is what the lighting calculations expect, e.g. for a texture loaded with LoadCanvas.
+func (c *Canvas) SRGBToLinear() {
+	for i, color := range c.Pixels {
+		c.Pixels[i] = color.SRGBToLinear()
+	}
+}
+
+// Converts the colors from linear values to sRGB, e.g. for a render that used linear
+// textures (see SRGBToLinear), before saving it.
+func (c *Canvas) LinearToSRGB() {
+	for i, color := range c.Pixels {
+		c.Pixels[i] = color.LinearToSRGB()
+	}
+}
+
 // Export canvas to an image from Go's std lib.
 func (c *Canvas) ToImage() image.Image {
 	target := image.NewRGBA(image.Rect(0, 0, c.Width, c.Height))
@@ -3,7 +3,11 @@ package raytracer
 import (
 	"errors"
 	"fmt"
+	"image"
+	"image/color"
+	"image/png"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -214,6 +218,151 @@ func TestPpmParsingRespectsTheScaleSetting(t *testing.T) {
 	assertEqualColor(t, NewColor(0.75, 0.5, 0.25), c.PixelAt(0, 1))
 }
 
+func TestReadingAFlatHdrFile(t *testing.T) {
+	hdr := []byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 2\n")
+	hdr = append(hdr,
+		128, 64, 32, 129, // (0.5, 0.25, 0.125) * 2
+		0, 0, 0, 0, // black
+	)
+	c, err := NewCanvasFromHdr(hdr)
+	assertNil(t, err)
+	assertEqualInt(t, 2, c.Width)
+	assertEqualInt(t, 1, c.Height)
+	assertEqualColor(t, NewColor(1, 0.5, 0.25), c.PixelAt(0, 0))
+	assertEqualColor(t, NewColor(0, 0, 0), c.PixelAt(1, 0))
+}
+
+func TestReadingARunLengthEncodedHdrFile(t *testing.T) {
+	hdr := []byte("#?RADIANCE\n\n-Y 1 +X 8\n")
+	hdr = append(hdr,
+		2, 2, 0, 8, // new-style scanline of width 8
+		136, 128, // red: 8 x 128
+		136, 64, // green: 8 x 64
+		4, 1, 2, 3, 4, 132, 0, // blue: 1, 2, 3, 4, then 4 x 0
+		136, 136, // exponent: 8 x 136 (i.e. multiply by 1)
+	)
+	c, err := NewCanvasFromHdr(hdr)
+	assertNil(t, err)
+	assertEqualColor(t, NewColor(128, 64, 1), c.PixelAt(0, 0))
+	assertEqualColor(t, NewColor(128, 64, 4), c.PixelAt(3, 0))
+	assertEqualColor(t, NewColor(128, 64, 0), c.PixelAt(7, 0))
+}
+
+func TestReadingAnInvalidHdrFile(t *testing.T) {
+	_, err := NewCanvasFromHdr([]byte("P3\n1 1\n255\n0 0 0\n"))
+	assertEqualError(t, fmt.Errorf("raytracer.NewCanvasFromHdr: invalid hdr file, started with \"P3\" instead of #?."), err)
+}
+
+func TestReadingABinaryPpmFile(t *testing.T) {
+	ppm := "P6\n# a comment\n2 1\n255\n" + string([]byte{255, 0, 51, 0, 102, 255})
+	c, err := NewCanvasFromPpm(ppm)
+
+	assertNil(t, err)
+	assertEqualInt(t, 2, c.Width)
+	assertEqualInt(t, 1, c.Height)
+	assertEqualColor(t, NewColor(1, 0, 0.2), c.PixelAt(0, 0))
+	assertEqualColor(t, NewColor(0, 0.4, 1), c.PixelAt(1, 0))
+}
+
+func TestReadingABinaryPpmFileWithTwoBytesPerSample(t *testing.T) {
+	ppm := "P6 1 1 65535\n" + string([]byte{0xFF, 0xFF, 0x80, 0x00, 0x00, 0x00})
+	c, err := NewCanvasFromPpm(ppm)
+
+	assertNil(t, err)
+	assertEqualColor(t, NewColor(1, 32768.0/65535, 0), c.PixelAt(0, 0))
+}
+
+func TestReadingATruncatedBinaryPpmFile(t *testing.T) {
+	_, err := NewCanvasFromPpm("P6 2 2 255\n" + string([]byte{1, 2, 3}))
+
+	assertEqualError(t, errors.New("raytracer.NewCanvasFromPpm: invalid ppm file, expected 12 bytes of pixels but found 3."), err)
+}
+
+func TestCanvasFromAnImageKeepsItsColors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(10, 10, 12, 11)) // NB: bounds don't start at 0
+	img.Set(10, 10, color.RGBA{255, 0, 0, 255})
+	img.Set(11, 10, color.RGBA{128, 128, 128, 255})
+	c := NewCanvasFromImage(img)
+
+	assertEqualInt(t, 2, c.Width)
+	assertEqualInt(t, 1, c.Height)
+	assertEqualColor(t, NewColor(1, 0, 0), c.PixelAt(0, 0))
+	assertEqualColor(t, NewColor(0.50196, 0.50196, 0.50196), c.PixelAt(1, 0))
+}
+
+func TestConvertingACanvasBetweenSRGBAndLinear(t *testing.T) {
+	c := NewCanvas(2, 1)
+	c.WritePixel(0, 0, NewColor(1, 0, 0))
+	c.WritePixel(1, 0, NewColor(0.5, 0.5, 0.5))
+
+	c.SRGBToLinear()
+	assertEqualColor(t, NewColor(1, 0, 0), c.PixelAt(0, 0))
+	assertEqualColor(t, NewColor(0.21404, 0.21404, 0.21404), c.PixelAt(1, 0))
+	c.LinearToSRGB()
+	assertEqualColor(t, NewColor(1, 0, 0), c.PixelAt(0, 0))
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), c.PixelAt(1, 0))
+}
+
+func TestLoadingACanvasFromAPngFile(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.RGBA{255, 255, 0, 255})
+	path := filepath.Join(t.TempDir(), "yellow.png")
+	f, err := os.Create(path)
+	assertNil(t, err)
+	assertNil(t, png.Encode(f, img))
+	f.Close()
+
+	c, err := LoadCanvas(path)
+	assertNil(t, err)
+	assertEqualColor(t, NewColor(1, 1, 0), c.PixelAt(0, 0))
+
+	_, err = LoadCanvas(filepath.Join(t.TempDir(), "missing.png"))
+	assert(t, err != nil)
+}
+
+func TestLoadingAndSavingAnImageGivesBackTheSamePixels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		img.Set(x, 0, color.RGBA{uint8(x), uint8(255 - x), uint8(x / 2), 255})
+	}
+	path := filepath.Join(t.TempDir(), "gradient.png")
+	f, err := os.Create(path)
+	assertNil(t, err)
+	assertNil(t, png.Encode(f, img))
+	f.Close()
+
+	testCases := []struct {
+		name   string
+		linear bool
+	}{
+		{"as it is", false},
+		{"converted to linear and back", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := LoadCanvas(path)
+			assertNil(t, err)
+			if tc.linear {
+				c.SRGBToLinear()
+				c.LinearToSRGB()
+			}
+			savedPath := filepath.Join(t.TempDir(), "saved.png")
+			assertNil(t, c.SavePNG(savedPath))
+
+			saved, err := LoadCanvas(savedPath)
+			assertNil(t, err)
+			for x := 0; x < 256; x++ {
+				expected := img.RGBAAt(x, 0)
+				r, g, b, _ := saved.PixelAt(x, 0).RGBA()
+				actual := color.RGBA{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255}
+				if expected != actual {
+					t.Errorf("Expected pixel %d to be %v, but got %v", x, expected, actual)
+				}
+			}
+		})
+	}
+}
+
 /////////////
 // Benchmarks
 /////////////
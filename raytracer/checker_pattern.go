@@ -6,12 +6,20 @@ import (
 )
 
 type CheckerPattern struct {
-	A Color
-	B Color
+	A        Color
+	B        Color
+	PatternA *Pattern // if set, used instead of A
+	PatternB *Pattern // if set, used instead of B
 }
 
 func NewCheckerPattern(a, b Color) *Pattern {
-	return NewPattern(CheckerPattern{a, b})
+	return NewPattern(CheckerPattern{A: a, B: b})
+}
+
+// Returns a checker pattern of two other patterns, which each keep their own
+// transformation (relative to this pattern's).
+func NewCheckerPatternFromPatterns(a, b *Pattern) *Pattern {
+	return NewPattern(CheckerPattern{PatternA: a, PatternB: b})
 }
 
 func (p CheckerPattern) String() string {
@@ -30,9 +38,9 @@ func (p CheckerPattern) LocalPatternAt(point Tuple) Color {
 	// 	return p.B
 	// }
 	if math.Mod(math.Floor(point.X)+math.Floor(point.Y)+math.Floor(point.Z), 2) == 0 {
-		return p.A
+		return colorOrPatternAt(p.A, p.PatternA, point)
 	} else {
-		return p.B
+		return colorOrPatternAt(p.B, p.PatternB, point)
 	}
 }
 
@@ -41,9 +49,11 @@ func (p CheckerPattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (cp CheckerPattern) localIsEqualTo(cp2 PatternInterface) bool {
-	cp2Pattern := cp2.(*CheckerPattern)
+	cp2Pattern := cp2.(CheckerPattern)
 	if !cp.A.IsEqualTo(cp2Pattern.A) || !cp.B.IsEqualTo(cp2Pattern.B) {
 		return false
+	} else if !patternsAreEqual(cp.PatternA, cp2Pattern.PatternA) || !patternsAreEqual(cp.PatternB, cp2Pattern.PatternB) {
+		return false
 	}
 	return true
 }
@@ -66,6 +66,33 @@ func (c Color) MultiplyColor(c2 Color) Color {
 	return NewColor(c.Red*c2.Red, c.Green*c2.Green, c.Blue*c2.Blue)
 }
 
+// Returns the perceived brightness of the color (Rec. 709 weights).
+func (c Color) Luminance() float64 {
+	return 0.2126*c.Red + 0.7152*c.Green + 0.0722*c.Blue
+}
+
+// Returns the color converted from sRGB (i.e. how most images are encoded) to linear values.
+func (c Color) SRGBToLinear() Color {
+	toLinear := func(v float64) float64 {
+		if v <= 0.04045 {
+			return v / 12.92
+		}
+		return math.Pow((v+0.055)/1.055, 2.4)
+	}
+	return NewColor(toLinear(c.Red), toLinear(c.Green), toLinear(c.Blue))
+}
+
+// Returns the color converted from linear values to sRGB (the opposite of SRGBToLinear).
+func (c Color) LinearToSRGB() Color {
+	toSRGB := func(v float64) float64 {
+		if v <= 0.0031308 {
+			return v * 12.92
+		}
+		return 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return NewColor(toSRGB(c.Red), toSRGB(c.Green), toSRGB(c.Blue))
+}
+
 func (c Color) ScaledRGB(colorScale float64) (uint, uint, uint) {
 	return uint(math.Ceil(math.Min(colorScale, math.Max(0, c.Red*colorScale)))),
 		uint(math.Ceil(math.Min(colorScale, math.Max(0, c.Green*colorScale)))),
@@ -74,6 +74,24 @@ func TestRGBA(t *testing.T) {
 	assertEqualUInt(t, 65535, uint(a))
 }
 
+func TestLuminanceOfAColor(t *testing.T) {
+	assertEqualFloat64(t, 1, Colors["White"].Luminance())
+	assertEqualFloat64(t, 0, Colors["Black"].Luminance())
+	assertEqualFloat64(t, 0.7152, Colors["Green"].Luminance())
+}
+
+func TestConvertingAColorFromSRGBToLinear(t *testing.T) {
+	assertEqualColor(t, Colors["White"], Colors["White"].SRGBToLinear())
+	assertEqualColor(t, Colors["Black"], Colors["Black"].SRGBToLinear())
+	assertEqualColor(t, NewColor(0.21404, 0.00232, 0.0), NewColor(0.5, 0.03, 0).SRGBToLinear())
+}
+
+func TestConvertingAColorFromLinearToSRGB(t *testing.T) {
+	assertEqualColor(t, Colors["White"], Colors["White"].LinearToSRGB())
+	assertEqualColor(t, Colors["Black"], Colors["Black"].LinearToSRGB())
+	assertEqualColor(t, NewColor(0.5, 0.03, 0.0), NewColor(0.5, 0.03, 0).SRGBToLinear().LinearToSRGB())
+}
+
 /////////////
 // Benchmarks
 /////////////
@@ -122,6 +122,16 @@ func (cone Cone) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
 	}
 }
 
+// Fulfills UVShapeInterface, the same way as Cylinder.LocalUVAt.
+func (cone Cone) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	if cone.Closed && localPoint.Y >= cone.Maximum-EPSILON {
+		return capUV(localPoint, math.Abs(cone.Maximum), true)
+	} else if cone.Closed && localPoint.Y <= cone.Minimum+EPSILON {
+		return capUV(localPoint, math.Abs(cone.Minimum), false)
+	}
+	return sideUV(localPoint, cone.Minimum, cone.Maximum)
+}
+
 func (cone Cone) localIsEqualTo(c2 ShapeInterface) bool {
 	// NB I still don't know why we need to do a type assertion to *Cone
 	// instead of Cone, but it fixes this panic when comparing two
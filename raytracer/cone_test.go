@@ -90,6 +90,30 @@ func TestComputingTheNormalVectorOnACone(t *testing.T) {
 	}
 }
 
+func TestUVsOnACappedConeWithItsTipAtTheTop(t *testing.T) {
+	shape := NewCone()
+	cone := shape.LocalShape.(*Cone)
+	cone.Minimum = -1
+	cone.Maximum = 0
+	cone.Closed = true
+	testCases := []struct {
+		Point Tuple
+		U     float64
+		V     float64
+	}{
+		{NewPoint(0, 0, 0), 0.5, 0.5},
+		{NewPoint(0.0000001, -0.0000001, 0), 0.5, 0.5},
+		{NewPoint(0, -1, 0.5), 0.5, 0.75},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("UV at point %v", tc.Point), func(t *testing.T) {
+			u, v := cone.LocalUVAt(tc.Point, nil)
+			assertEqualFloat64(t, tc.U, u)
+			assertEqualFloat64(t, tc.V, v)
+		})
+	}
+}
+
 func TestAnUnboundedConeHasABoundingBox(t *testing.T) {
 	c := NewCone()
 	b := c.Bounds()
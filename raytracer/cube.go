@@ -81,6 +81,24 @@ func (c Cube) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
 	}
 }
 
+// Fulfills UVShapeInterface: each face has the whole texture, e.g. with CubeUVFront.
+func (c Cube) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	switch FaceFromPoint(localPoint) {
+	case "left":
+		return CubeUVLeft(localPoint)
+	case "right":
+		return CubeUVRight(localPoint)
+	case "front":
+		return CubeUVFront(localPoint)
+	case "back":
+		return CubeUVBack(localPoint)
+	case "up":
+		return CubeUVUpper(localPoint)
+	default:
+		return CubeUVLower(localPoint)
+	}
+}
+
 func (c Cube) localIsEqualTo(c2 ShapeInterface) bool {
 	// NB I still don't know why we need to do a type assertion to *Cube
 	// instead of Cube, but it fixes this panic when comparing two
@@ -2,6 +2,7 @@ package raytracer
 
 import (
 	"fmt"
+	"math"
 )
 
 type CubeMapPattern struct {
@@ -27,6 +28,11 @@ func (p CubeMapPattern) String() string {
 
 func (p CubeMapPattern) LocalPatternAt(point Tuple) Color {
 	var u, v float64
+
+	// Project points that aren't on the unit cube (e.g. directions) onto it.
+	if coord := maxFloat64(math.Abs(point.X), math.Abs(point.Y), math.Abs(point.Z)); coord > 0 && coord != 1 {
+		point = NewPoint(point.X/coord, point.Y/coord, point.Z/coord)
+	}
 	face := FaceFromPoint(point)
 
 	switch face {
@@ -56,7 +62,7 @@ func (p CubeMapPattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (p CubeMapPattern) localIsEqualTo(p2 PatternInterface) bool {
-	p2Pattern := p2.(*CubeMapPattern)
+	p2Pattern := p2.(CubeMapPattern)
 	if !p.left.IsEqualTo(p2Pattern.left) {
 		return false
 	} else if !p.right.IsEqualTo(p2Pattern.right) {
@@ -110,6 +110,42 @@ func (cyl Cylinder) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
 	}
 }
 
+// Fulfills UVShapeInterface: u goes around the cylinder (like CylindricalMap), and v goes
+// from Minimum to Maximum (or repeats every unit if it's infinite). Caps have the texture
+// laid across them, as seen from outside the cylinder.
+func (cyl Cylinder) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	distance := math.Pow(localPoint.X, 2) + math.Pow(localPoint.Z, 2)
+	if cyl.Closed && distance < 1 && localPoint.Y >= cyl.Maximum-EPSILON {
+		return capUV(localPoint, 1, true)
+	} else if cyl.Closed && distance < 1 && localPoint.Y <= cyl.Minimum+EPSILON {
+		return capUV(localPoint, 1, false)
+	}
+	return sideUV(localPoint, cyl.Minimum, cyl.Maximum)
+}
+
+// Returns the uv of a point on the side of a cylinder or cone between min and max.
+func sideUV(localPoint Tuple, min, max float64) (float64, float64) {
+	u, v := CylindricalMap(localPoint)
+	if !math.IsInf(min, 0) && !math.IsInf(max, 0) {
+		v = (localPoint.Y - min) / (max - min)
+	}
+	return u, v
+}
+
+// Returns the uv of a point on the cap of a cylinder or cone with the given radius.
+// A cap without a radius (e.g. at the tip of a cone) is just its center.
+func capUV(localPoint Tuple, radius float64, top bool) (float64, float64) {
+	if radius < EPSILON {
+		return 0.5, 0.5
+	}
+	u := (localPoint.X/radius + 1) / 2
+	v := (localPoint.Z/radius + 1) / 2
+	if top {
+		v = 1 - v
+	}
+	return u, v
+}
+
 func (c Cylinder) localIsEqualTo(c2 ShapeInterface) bool {
 	// NB I still don't know why we need to do a type assertion to *Cylinder
 	// instead of Cylinder, but it fixes this panic when comparing two
@@ -72,6 +72,31 @@ func TestNormalVectorOnACylinder(t *testing.T) {
 	}
 }
 
+func TestUVsOnACappedCylinder(t *testing.T) {
+	shape := NewCylinder()
+	cyl := shape.LocalShape.(*Cylinder)
+	cyl.Minimum = 1
+	cyl.Maximum = 2
+	cyl.Closed = true
+	testCases := []struct {
+		Point Tuple
+		U     float64
+		V     float64
+	}{
+		{NewPoint(0, 1.5, -1), 0, 0.5},
+		{NewPoint(1, 1.25, 0), 0.25, 0.25},
+		{NewPoint(0.5, 2, 0), 0.75, 0.5},
+		{NewPoint(0, 1, 0.5), 0.5, 0.75},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("UV at point %v", tc.Point), func(t *testing.T) {
+			u, v := cyl.LocalUVAt(tc.Point, nil)
+			assertEqualFloat64(t, tc.U, u)
+			assertEqualFloat64(t, tc.V, v)
+		})
+	}
+}
+
 func TestTheDefaultMinimumAndMaximumForACylinder(t *testing.T) {
 	shape := NewCylinder()
 	cyl := shape.LocalShape.(*Cylinder)
@@ -0,0 +1,143 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EnvironmentLight lights the scene from every direction with an equirectangular
+// (aka "lat-long") image, e.g. an HDR photo of a studio or the sky. Directions are
+// importance-sampled by the image's luminance, so that bright areas (the sun, a
+// window, a softbox) get most of the shadow rays.
+//
+// The image uses the same mapping as SphericalMap, so the same canvas can be used
+// for World.Background with NewTextureMapPattern(NewUVImagePattern(c), SphericalMap).
+type EnvironmentLight struct {
+	Canvas    Canvas
+	Intensity float64   // scales the image's colors
+	Samples   int       // number of directions sampled for each shaded point
+	Jitter    *Sequence // each pixel starts at a different place in it (see PixelJitter)
+
+	rowCdf     []float64   // cumulative distribution of picking each row (len: Height+1)
+	columnCdfs [][]float64 // cumulative distribution of picking each column, per row (len: Width+1)
+	total      float64     // sum of all pixel weights
+}
+
+// Returns an EnvironmentLight for the given image, which builds the distribution
+// used to sample it up front.
+func NewEnvironmentLight(c Canvas, intensity float64, samples int) *EnvironmentLight {
+	jitter := NewRandomSequence(1, 4096)
+	el := &EnvironmentLight{
+		Canvas:    c,
+		Intensity: intensity,
+		Samples:   samples,
+		Jitter:    &jitter,
+	}
+
+	// Each pixel's weight is its luminance, scaled by the solid angle it covers,
+	// which shrinks towards the poles.
+	el.rowCdf = make([]float64, c.Height+1)
+	el.columnCdfs = make([][]float64, c.Height)
+	for y := 0; y < c.Height; y++ {
+		sinTheta := math.Sin(math.Pi * (float64(y) + 0.5) / float64(c.Height))
+		cdf := make([]float64, c.Width+1)
+		for x := 0; x < c.Width; x++ {
+			cdf[x+1] = cdf[x] + c.PixelAt(x, y).Luminance()*sinTheta
+		}
+		el.rowCdf[y+1] = el.rowCdf[y] + cdf[c.Width]
+		el.columnCdfs[y] = cdf
+	}
+	el.total = el.rowCdf[c.Height]
+
+	return el
+}
+
+func (el *EnvironmentLight) String() string {
+	return fmt.Sprintf(
+		"EnvironmentLight(\n  Canvas: %v\n  Intensity: %v\n  Samples: %v\n)",
+		el.Canvas,
+		el.Intensity,
+		el.Samples,
+	)
+}
+
+// Picks a direction with probability proportional to its pixel's weight, given
+// two numbers in [0, 1). Returns the direction, its radiance, and its probability
+// density (per solid angle).
+func (el *EnvironmentLight) SampleDirection(su, sv float64) (Tuple, Color, float64) {
+	if el.total <= 0 {
+		return NewVector(0, 1, 0), Colors["Black"], 0
+	}
+
+	y, dy := sampleCdf(el.rowCdf, su*el.total)
+	columnCdf := el.columnCdfs[y]
+	x, dx := sampleCdf(columnCdf, sv*columnCdf[len(columnCdf)-1])
+
+	u := (float64(x) + dx) / float64(el.Canvas.Width)
+	theta := math.Pi * (float64(y) + dy) / float64(el.Canvas.Height) // polar angle, from the top
+	phi := (0.5 - u) * 2 * math.Pi                                   // azimuthal angle (see SphericalMap)
+	sinTheta := math.Sin(theta)
+	direction := NewVector(sinTheta*math.Sin(phi), math.Cos(theta), sinTheta*math.Cos(phi))
+
+	if sinTheta <= 0 {
+		return direction, Colors["Black"], 0
+	}
+	pixelProbability := (columnCdf[x+1] - columnCdf[x]) / el.total
+	pdf := pixelProbability * float64(el.Canvas.Width*el.Canvas.Height) / (2 * math.Pi * math.Pi * sinTheta)
+	radiance := el.Canvas.PixelAt(x, y).Multiply(el.Intensity)
+
+	return direction, radiance, pdf
+}
+
+// Returns the diffuse light that reaches the point+normal in the computation
+// from the environment, as a Monte Carlo estimate over Samples shadow rays.
+// Specular highlights aren't included: mirror-like surfaces see the environment
+// through their reflections instead.
+func (el *EnvironmentLight) Lighting(w *World, c *Computation) Color {
+	sum := Colors["Black"]
+	if el.Samples < 1 {
+		return sum
+	}
+
+	jitter := c.Jitter.Sequence(el.Jitter)
+	for i := 0; i < el.Samples; i++ {
+		direction, radiance, pdf := el.SampleDirection(jitter.Next(), jitter.Next())
+		cosine := direction.Dot(c.NormalV)
+		if pdf <= 0 || cosine <= 0 {
+			continue
+		}
+		xs := w.Intersect(NewRay(c.OverPoint, direction))
+		if hit := xs.Hit(true); hit != nil {
+			continue // the environment is blocked in this direction
+		}
+		sum = sum.Add(radiance.Multiply(cosine / pdf))
+	}
+
+	m := c.Material
+	// A lambertian surface reflects 1/π of the irradiance, so that a uniform
+	// environment with radiance 1 lights it like a point light with intensity 1.
+	return m.colorAtComputation(c).
+		MultiplyColor(m.Diffuse).
+		MultiplyColor(sum).
+		Divide(math.Pi * float64(el.Samples))
+}
+
+// Finds the bucket of a cumulative distribution that contains value, and how far
+// into that bucket (0..1) the value is.
+func sampleCdf(cdf []float64, value float64) (int, float64) {
+	n := len(cdf) - 1
+	idx := sort.Search(n, func(i int) bool { return cdf[i+1] > value })
+	if idx >= n {
+		idx = n - 1
+	}
+	// Skip empty buckets (e.g. black pixels), which can never be picked.
+	for idx < n-1 && cdf[idx+1] == cdf[idx] {
+		idx++
+	}
+	offset := 0.5
+	if width := cdf[idx+1] - cdf[idx]; width > 0 {
+		offset = math.Min(1, math.Max(0, (value-cdf[idx])/width))
+	}
+	return idx, offset
+}
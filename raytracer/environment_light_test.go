@@ -0,0 +1,123 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestAnEnvironmentLightOnlySamplesBrightPixels(t *testing.T) {
+	c := NewCanvas(8, 4)
+	c.WritePixel(2, 1, NewColor(10, 10, 10))
+	light := NewEnvironmentLight(c, 1, 1)
+
+	testCases := []struct {
+		su float64
+		sv float64
+	}{
+		{0, 0},
+		{0.25, 0.75},
+		{0.5, 0.5},
+		{0.99, 0.01},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			direction, radiance, pdf := light.SampleDirection(tc.su, tc.sv)
+			assertEqualFloat64(t, 1, direction.Magnitude())
+			assertEqualColor(t, NewColor(10, 10, 10), radiance)
+			assert(t, pdf > 0)
+
+			// Map the direction back onto the image; it should be the bright pixel.
+			u, v := SphericalMap(NewPoint(direction.X, direction.Y, direction.Z))
+			assertEqualInt(t, 2, int(u*float64(c.Width)))
+			assertEqualInt(t, 1, int((1-v)*float64(c.Height)))
+		})
+	}
+}
+
+func TestAnEnvironmentLightScalesItsRadianceByIntensity(t *testing.T) {
+	c := NewCanvas(4, 2, NewColor(0.5, 0.5, 0.5))
+	light := NewEnvironmentLight(c, 3, 1)
+
+	_, radiance, _ := light.SampleDirection(0.3, 0.3)
+	assertEqualColor(t, NewColor(1.5, 1.5, 1.5), radiance)
+}
+
+func TestAUniformEnvironmentLightsAnOpenSurfaceLikeAPointLight(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	floor.Material.Diffuse = NewColor(1, 1, 1)
+	w.Objects = []*Shape{floor}
+	w.EnvironmentLight = NewEnvironmentLight(NewCanvas(32, 16, Colors["White"]), 1, 4000)
+
+	r := NewRay(NewPoint(0, 1, -1), NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2))
+	c := NewIntersection(math.Sqrt(2), floor).PrepareComputations(r)
+	result := w.EnvironmentLight.Lighting(w, c)
+
+	assert(t, math.Abs(result.Red-1) < 0.05)
+	assert(t, math.Abs(result.Green-1) < 0.05)
+	assert(t, math.Abs(result.Blue-1) < 0.05)
+}
+
+func TestAnEnvironmentLightIsBlockedByObjects(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	ceiling := NewPlane()
+	ceiling.SetTransform(NewTranslation(0, 2, 0))
+	w.Objects = []*Shape{floor, ceiling}
+	w.EnvironmentLight = NewEnvironmentLight(NewCanvas(16, 8, Colors["White"]), 1, 100)
+
+	r := NewRay(NewPoint(0, 1, 0), NewVector(0, -1, 0))
+	c := NewIntersection(1, floor).PrepareComputations(r)
+
+	assertEqualColor(t, Colors["Black"], w.EnvironmentLight.Lighting(w, c))
+}
+
+func TestShadeHitIncludesTheEnvironmentLight(t *testing.T) {
+	w := NewWorld()
+	floor := NewPlane()
+	floor.Material.Ambient = Colors["Black"]
+	w.Objects = []*Shape{floor}
+	r := NewRay(NewPoint(0, 1, 0), NewVector(0, -1, 0))
+	c := NewIntersection(1, floor).PrepareComputations(r)
+
+	assertEqualColor(t, Colors["Black"], w.ShadeHit(c, DefaultMaximumReflections))
+
+	w.EnvironmentLight = NewEnvironmentLight(NewCanvas(16, 8, Colors["White"]), 1, 100)
+	color := w.ShadeHit(c, DefaultMaximumReflections)
+	assert(t, color.Red > 0.5)
+}
+
+func TestRenderingAnEnvironmentLightInParallelIsDeterministic(t *testing.T) {
+	sky := NewCanvas(16, 8)
+	for x := 0; x < sky.Width; x++ {
+		sky.WritePixel(x, 1, Colors["White"])
+	}
+	w := NewWorld()
+	floor := NewPlane()
+	ball := NewSphere()
+	ball.SetTransform(NewTranslation(0, 1, 0))
+	w.Objects = []*Shape{floor, ball}
+	w.EnvironmentLight = NewEnvironmentLight(sky, 1, 4)
+	c := NewCamera(16, 16, math.Pi/2)
+	c.SetTransform(NewViewTransform(NewPoint(0, 3, -3), NewPoint(0, 0, 0), NewVector(0, 1, 0)))
+
+	expected := c.Render(w, 1, false)
+	actual := c.Render(w, 8, false)
+	for y := 0; y < c.VSize; y++ {
+		for x := 0; x < c.HSize; x++ {
+			assertEqualColor(t, expected.PixelAt(x, y), actual.PixelAt(x, y))
+		}
+	}
+}
+
+/////////////
+// Benchmarks
+/////////////
+
+func BenchmarkEnvironmentLightMethodSampleDirection(b *testing.B) {
+	light := NewEnvironmentLight(NewCanvas(64, 32, Colors["White"]), 1, 1)
+	for i := 0; i < b.N; i++ {
+		light.SampleDirection(0.3, 0.6)
+	}
+}
@@ -0,0 +1,30 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Fog is a uniform haze that fills the whole World: the farther a ray travels,
+// the more of its color is replaced by the fog's Color (exponentially).
+type Fog struct {
+	Density float64 // how quickly the fog thickens, per unit of distance
+	Color   Color
+}
+
+func NewFog(density float64, color Color) *Fog {
+	return &Fog{Density: density, Color: color}
+}
+
+func (f *Fog) String() string {
+	return fmt.Sprintf("Fog( Density: %v Color: %v )", f.Density, f.Color)
+}
+
+// Returns what's left of color after it has traveled distance through the fog.
+func (f *Fog) Apply(color Color, distance float64) Color {
+	if f.Density <= 0 {
+		return color
+	}
+	transmittance := math.Exp(-f.Density * distance)
+	return color.Multiply(transmittance).Add(f.Color.Multiply(1 - transmittance))
+}
@@ -0,0 +1,53 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFogApply(t *testing.T) {
+	fog := NewFog(0.5, NewColor(0.5, 0.5, 0.5))
+	testCases := []struct {
+		distance float64
+		result   Color
+	}{
+		{0, NewColor(1, 0, 0)},
+		{2, NewColor(0.5+0.5*math.Exp(-1), 0.5-0.5*math.Exp(-1), 0.5-0.5*math.Exp(-1))},
+		{math.Inf(1), NewColor(0.5, 0.5, 0.5)},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, fog.Apply(NewColor(1, 0, 0), tc.distance))
+		})
+	}
+}
+
+func TestFogWithoutDensityDoesNothing(t *testing.T) {
+	fog := NewFog(0, NewColor(0.5, 0.5, 0.5))
+
+	assertEqualColor(t, NewColor(1, 0, 0), fog.Apply(NewColor(1, 0, 0), math.Inf(1)))
+}
+
+func TestColorAtWithFog(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewSolidPattern(Colors["White"])
+	w.Fog = NewFog(0.1, Colors["Black"])
+	wall := NewPlane()
+	wall.SetTransform(NewTranslation(0, 0, 10))
+	wall.SetTransform(wall.Transform.Multiply(NewRotateX(math.Pi / 2)))
+	wall.Material.Ambient = Colors["White"]
+	wall.Material.Diffuse = Colors["Black"]
+	wall.Material.Specular = Colors["Black"]
+	wall.Material.Color = Colors["White"]
+	w.Objects = []*Shape{wall}
+	w.Lights = []*AreaLight{NewPointLight(NewPoint(0, 0, 0), Colors["White"])}
+
+	r := NewRay(NewPoint(0, 0, 0), NewVector(0, 0, 1))
+	expected := Colors["White"].Multiply(math.Exp(-1))
+	assertEqualColor(t, expected, w.ColorAt(r, DefaultMaximumReflections))
+
+	// A miss is infinitely far away, so it's lost in the fog
+	r = NewRay(NewPoint(0, 0, 0), NewVector(0, 0, -1))
+	assertEqualColor(t, Colors["Black"], w.ColorAt(r, DefaultMaximumReflections))
+}
@@ -0,0 +1,790 @@
+package raytracer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"image"
+	"io/ioutil"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// How many pixels wide (or high, if it's taller than it is wide) the Cameras of a GltfFile are.
+const DefaultGltfCameraSize = 640
+
+// How far away directional lights are put, since there are only point lights here.
+const gltfDirectionalLightDistance = 10000
+
+type GltfFile struct {
+	Scene            *Shape       // a group of the scene's nodes, with groups for their children
+	Cameras          []*Camera    // the perspective cameras of the scene's nodes, positioned like them
+	Lights           []*AreaLight // the KHR_lights_punctual lights of the scene's nodes (see ParseGltf)
+	LightIntensities []float64    // the lights' intensities in the file: candela for point and spot lights, lux for directional ones
+	Materials        []*Material  // the file's materials, by index
+}
+
+// Reads and parses a glTF 2.0 file: either a .gltf (JSON) file, whose buffers and images
+// are loaded relative to it, or a binary .glb file (see ParseGltf).
+func LoadGltfFile(path string, options ObjOptions) (*GltfFile, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGltf(data, filepath.Dir(path), options)
+}
+
+// Parses a glTF 2.0 file (JSON, or binary, i.e. GLB) into shapes, loading external buffers
+// and images relative to dir. Data URIs are supported too, but not anything on a network.
+//   - The nodes of the scene (the default one, or the first one) are groups, with their
+//     transforms, their children and the triangles of their meshes. Triangles without
+//     normals get them generated if options.SmoothNormals is set (see ObjOptions), and
+//     vertex colors (COLOR_0) are blended across them (like a PLY file's, see ParsePly).
+//   - Materials use the PBRModel, with the base color (or texture), metallic and roughness
+//     factors, and transparency for "BLEND" materials.
+//   - Perspective cameras (but not orthographic ones) are DefaultGltfCameraSize pixels
+//     across, at their aspect ratio (4:3 if they don't have one). NB if you resize them,
+//     keep the aspect ratio, since their FieldOfView depends on it.
+//   - Point lights are point lights, spot lights are SpotLights, and directional lights are
+//     point lights that are far away. glTF intensities are in candela (for point and spot
+//     lights, whose light falls off with the square of the distance) or lux (for directional
+//     lights), but lights here don't fall off. So each light's Intensity is its color, scaled
+//     by how bright it is at the center of the scene (or a meter away from the light, if
+//     that's closer), relative to the brightest light. Their intensities in the file are
+//     in LightIntensities, for converting them some other way.
+func ParseGltf(data []byte, dir string, options ObjOptions) (*GltfFile, error) {
+	l := &gltfLoader{dir: dir, options: options, textures: map[int]*Pattern{}, meshes: map[int][]*Shape{}}
+
+	jsonChunk := data
+	if bytes.HasPrefix(data, []byte("glTF")) {
+		var err error
+		if jsonChunk, l.bin, err = splitGlb(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(jsonChunk, &l.doc); err != nil {
+		return nil, fmt.Errorf("Invalid glTF file: %w\n", err)
+	}
+	if err := l.loadBuffers(); err != nil {
+		return nil, err
+	}
+
+	gf := &GltfFile{Scene: NewGroup()}
+	for idx := range l.doc.Materials {
+		m, err := l.material(idx)
+		if err != nil {
+			return nil, err
+		}
+		gf.Materials = append(gf.Materials, m)
+	}
+	l.materials = gf.Materials
+
+	if len(l.doc.Scenes) == 0 {
+		return gf, nil
+	}
+	sceneIdx := 0
+	if l.doc.Scene != nil {
+		sceneIdx = *l.doc.Scene
+	}
+	if err := checkGltfIndex("scene", sceneIdx, len(l.doc.Scenes)); err != nil {
+		return nil, err
+	}
+	for _, nodeIdx := range l.doc.Scenes[sceneIdx].Nodes {
+		node, err := l.node(gf, nodeIdx, IdentityMatrix(), 0)
+		if err != nil {
+			return nil, err
+		}
+		gf.Scene.AddChildren(node)
+	}
+	l.scaleLights(gf)
+	return gf, nil
+}
+
+// Splits a binary glTF file into its JSON chunk and (optional) binary chunk.
+func splitGlb(data []byte) ([]byte, []byte, error) {
+	if len(data) < 12 {
+		return nil, nil, fmt.Errorf("GLB file is too short for its header\n")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("GLB version %d isn't supported\n", version)
+	}
+
+	var jsonChunk, binChunk []byte
+	for offset := 12; offset+8 <= len(data); {
+		length := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if length < 0 || offset+length > len(data) {
+			return nil, nil, fmt.Errorf("GLB chunk is longer than the file\n")
+		}
+		switch chunkType {
+		case 0x4E4F534A: // "JSON"
+			jsonChunk = data[offset : offset+length]
+		case 0x004E4942: // "BIN"
+			binChunk = data[offset : offset+length]
+		}
+		offset += length
+	}
+	if jsonChunk == nil {
+		return nil, nil, fmt.Errorf("GLB file has no JSON chunk\n")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+func checkGltfIndex(kind string, idx, count int) error {
+	if idx < 0 || idx >= count {
+		return fmt.Errorf("glTF %s %d doesn't exist (there are %d)\n", kind, idx, count)
+	}
+	return nil
+}
+
+// The parts of a glTF file that are supported (see ParseGltf).
+type gltfDocument struct {
+	Scene  *int
+	Scenes []struct {
+		Nodes []int
+	}
+	Nodes []struct {
+		Name        string
+		Children    []int
+		Mesh        *int
+		Camera      *int
+		Matrix      []float64 // column-major
+		Translation []float64
+		Rotation    []float64 // a quaternion: x, y, z, w
+		Scale       []float64
+		Extensions  struct {
+			Light *struct {
+				Light int
+			} `json:"KHR_lights_punctual"`
+		}
+	}
+	Meshes []struct {
+		Name       string
+		Primitives []struct {
+			Attributes map[string]int
+			Indices    *int
+			Material   *int
+			Mode       *int
+		}
+	}
+	Accessors []struct {
+		BufferView    *int
+		ByteOffset    int
+		ComponentType int
+		Normalized    bool
+		Count         int
+		Type          string
+		Sparse        *json.RawMessage
+	}
+	BufferViews []struct {
+		Buffer     int
+		ByteOffset int
+		ByteLength int
+		ByteStride int
+	}
+	Buffers []struct {
+		URI string
+	}
+	Materials []struct {
+		Name                 string
+		AlphaMode            string
+		PbrMetallicRoughness *struct {
+			BaseColorFactor  []float64
+			BaseColorTexture *struct {
+				Index int
+			}
+			MetallicFactor  *float64
+			RoughnessFactor *float64
+		}
+	}
+	Textures []struct {
+		Sampler *int
+		Source  *int
+	}
+	Images []struct {
+		URI        string
+		BufferView *int
+	}
+	Samplers []struct {
+		MagFilter int
+		WrapS     int
+	}
+	Cameras []struct {
+		Type        string
+		Perspective *struct {
+			AspectRatio float64
+			Yfov        float64
+		}
+	}
+	Extensions struct {
+		Lights struct {
+			Lights []struct {
+				Type      string
+				Color     []float64
+				Intensity *float64
+				Spot      struct {
+					InnerConeAngle *float64
+					OuterConeAngle *float64
+				}
+			}
+		} `json:"KHR_lights_punctual"`
+	}
+}
+
+type gltfLoader struct {
+	doc             gltfDocument
+	dir             string
+	bin             []byte // the binary chunk of a GLB file
+	buffers         [][]byte
+	textures        map[int]*Pattern // so each texture's image is only decoded once
+	meshes          map[int][]*Shape // so each mesh is only parsed once
+	lightFalloffs   []bool           // whether each of the GltfFile's Lights falls off with distance (i.e. isn't directional)
+	materials       []*Material
+	defaultMaterial *Material // for primitives without a material
+	options         ObjOptions
+}
+
+// Loads the file's buffers, from the GLB's binary chunk, data URIs or files.
+func (l *gltfLoader) loadBuffers() error {
+	for idx, buffer := range l.doc.Buffers {
+		if buffer.URI == "" {
+			if l.bin == nil {
+				return fmt.Errorf("glTF buffer %d has no URI, and there's no GLB binary chunk\n", idx)
+			}
+			l.buffers = append(l.buffers, l.bin)
+			continue
+		}
+		data, err := l.loadURI(buffer.URI)
+		if err != nil {
+			return err
+		}
+		l.buffers = append(l.buffers, data)
+	}
+	return nil
+}
+
+// Returns the data of a data URI, or of a file relative to the glTF file.
+func (l *gltfLoader) loadURI(uri string) ([]byte, error) {
+	if strings.HasPrefix(uri, "data:") {
+		comma := strings.Index(uri, ",")
+		if comma < 0 || !strings.HasSuffix(uri[:comma], ";base64") {
+			return nil, fmt.Errorf("glTF data URIs must be base64\n")
+		}
+		return base64.StdEncoding.DecodeString(uri[comma+1:])
+	}
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(l.dir, filepath.FromSlash(path)))
+}
+
+// Returns a group for a node and its children, adding its camera and light to gf.
+// parentTransform is the transform of its parents, from their object space to world space.
+func (l *gltfLoader) node(gf *GltfFile, idx int, parentTransform Matrix, depth int) (*Shape, error) {
+	if err := checkGltfIndex("node", idx, len(l.doc.Nodes)); err != nil {
+		return nil, err
+	}
+	if depth > len(l.doc.Nodes) {
+		return nil, fmt.Errorf("glTF node %d is its own ancestor\n", idx)
+	}
+	n := l.doc.Nodes[idx]
+
+	transform := IdentityMatrix()
+	if len(n.Matrix) == 16 {
+		transform = NewMatrix(4, 4, append([]float64{}, n.Matrix...)).Transpose()
+	} else {
+		if len(n.Translation) == 3 {
+			transform = NewTranslation(n.Translation[0], n.Translation[1], n.Translation[2])
+		}
+		if len(n.Rotation) == 4 {
+			transform = transform.Multiply(NewRotateQuaternion(n.Rotation[0], n.Rotation[1], n.Rotation[2], n.Rotation[3]))
+		}
+		if len(n.Scale) == 3 {
+			transform = transform.Multiply(NewScale(n.Scale[0], n.Scale[1], n.Scale[2]))
+		}
+	}
+	worldTransform := parentTransform.Multiply(transform)
+
+	g := NewGroup()
+	g.Label = n.Name
+	g.SetTransform(transform)
+
+	if n.Mesh != nil {
+		mesh, err := l.mesh(*n.Mesh)
+		if err != nil {
+			return nil, err
+		}
+		g.AddChildren(mesh...)
+	}
+	if n.Camera != nil {
+		if err := checkGltfIndex("camera", *n.Camera, len(l.doc.Cameras)); err != nil {
+			return nil, err
+		}
+		if c := l.camera(*n.Camera, worldTransform); c != nil {
+			gf.Cameras = append(gf.Cameras, c)
+		}
+	}
+	if n.Extensions.Light != nil {
+		if err := checkGltfIndex("light", n.Extensions.Light.Light, len(l.doc.Extensions.Lights.Lights)); err != nil {
+			return nil, err
+		}
+		if light := l.light(n.Extensions.Light.Light, worldTransform); light != nil {
+			gl := l.doc.Extensions.Lights.Lights[n.Extensions.Light.Light]
+			intensity := 1.0
+			if gl.Intensity != nil {
+				intensity = *gl.Intensity
+			}
+			gf.Lights = append(gf.Lights, light)
+			gf.LightIntensities = append(gf.LightIntensities, intensity)
+			l.lightFalloffs = append(l.lightFalloffs, gl.Type != "directional")
+		}
+	}
+
+	for _, childIdx := range n.Children {
+		child, err := l.node(gf, childIdx, worldTransform, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		g.AddChildren(child)
+	}
+	return g, nil
+}
+
+// Returns a group of triangles for each of a mesh's primitives. Each mesh is only parsed
+// once: nodes that reuse it get copies of its groups (see copyShapeTree).
+func (l *gltfLoader) mesh(idx int) ([]*Shape, error) {
+	if groups, found := l.meshes[idx]; found {
+		copies := make([]*Shape, len(groups))
+		for i, group := range groups {
+			copies[i] = copyShapeTree(group)
+		}
+		return copies, nil
+	}
+
+	groups, err := l.parseMesh(idx)
+	if err != nil {
+		return nil, err
+	}
+	l.meshes[idx] = groups
+	return groups, nil
+}
+
+// Returns a copy of a shape and its children, since a shape can only be in one group (see
+// Shape.Parent). Their LocalShapes (e.g. the triangles) and materials are shared.
+func copyShapeTree(s *Shape) *Shape {
+	copied := &Shape{}
+	*copied = *s
+	copied.Parent = nil
+	if group, isGroup := s.LocalShape.(Group); isGroup {
+		group.Children = make([]*Shape, len(group.Children))
+		for i, child := range s.LocalShape.(Group).Children {
+			group.Children[i] = copyShapeTree(child)
+			group.Children[i].Parent = copied
+		}
+		copied.LocalShape = group
+	}
+	return copied
+}
+
+// Returns a group of triangles for each of a mesh's primitives. Primitives that aren't
+// triangles (i.e. points and lines) are skipped.
+func (l *gltfLoader) parseMesh(idx int) ([]*Shape, error) {
+	if err := checkGltfIndex("mesh", idx, len(l.doc.Meshes)); err != nil {
+		return nil, err
+	}
+
+	groups := []*Shape{}
+	for _, primitive := range l.doc.Meshes[idx].Primitives {
+		mode := 4 // TRIANGLES
+		if primitive.Mode != nil {
+			mode = *primitive.Mode
+		}
+		if mode < 4 {
+			continue
+		}
+
+		positionIdx, found := primitive.Attributes["POSITION"]
+		if !found {
+			continue
+		}
+		positions, err := l.accessor(positionIdx)
+		if err != nil {
+			return nil, err
+		}
+
+		// NB the mesh's triangles are put in an ObjFile, so they get the same normals and
+		// vertex colors as other mesh files.
+		of := newObjFile(l.options)
+		for _, p := range positions {
+			of.Vertices = append(of.Vertices, NewPoint(p[0], p[1], p[2]))
+		}
+		var normals, uvs []Tuple
+		if normalIdx, found := primitive.Attributes["NORMAL"]; found {
+			values, err := l.accessor(normalIdx)
+			if err != nil {
+				return nil, err
+			}
+			for _, n := range values {
+				normals = append(normals, NewVector(n[0], n[1], n[2]))
+			}
+		}
+		if uvIdx, found := primitive.Attributes["TEXCOORD_0"]; found {
+			values, err := l.accessor(uvIdx)
+			if err != nil {
+				return nil, err
+			}
+			for _, uv := range values {
+				uvs = append(uvs, NewPoint(uv[0], 1-uv[1], 0)) // glTF's v is down from the top of images
+			}
+		}
+		if colorIdx, found := primitive.Attributes["COLOR_0"]; found {
+			values, err := l.accessor(colorIdx)
+			if err != nil {
+				return nil, err
+			}
+			of.VertexColors = []Color{Colors["Black"]} // first element is dummy, like Vertices
+			for _, c := range values {
+				of.VertexColors = append(of.VertexColors, NewColor(c[0], c[1], c[2]))
+			}
+		}
+		if (normals != nil && len(normals) != len(positions)) ||
+			(uvs != nil && len(uvs) != len(positions)) ||
+			(of.VertexColors != nil && len(of.VertexColors) != len(of.Vertices)) {
+			return nil, fmt.Errorf("glTF mesh %d has attributes with different counts\n", idx)
+		}
+
+		if primitive.Material != nil {
+			if err := checkGltfIndex("material", *primitive.Material, len(l.materials)); err != nil {
+				return nil, err
+			}
+			of.Materials[of.CurrentMaterialName] = l.materials[*primitive.Material]
+		} else {
+			if l.defaultMaterial == nil {
+				l.defaultMaterial, _ = l.material(-1)
+			}
+			of.Materials[of.CurrentMaterialName] = l.defaultMaterial
+		}
+
+		indices := make([]int, len(positions))
+		for i := range indices {
+			indices[i] = i
+		}
+		if primitive.Indices != nil {
+			values, err := l.accessor(*primitive.Indices)
+			if err != nil {
+				return nil, err
+			}
+			indices = make([]int, len(values))
+			for i, v := range values {
+				if indices[i] = int(v[0]); indices[i] >= len(positions) {
+					return nil, fmt.Errorf("glTF mesh %d has index %d, but there are %d vertices\n", idx, indices[i], len(positions))
+				}
+			}
+		}
+
+		for _, tri := range gltfTriangles(mode, indices) {
+			if tri[0] == tri[1] || tri[1] == tri[2] || tri[0] == tri[2] {
+				continue // degenerate
+			}
+			var faceNormals, faceUVs []Tuple
+			if normals != nil {
+				faceNormals = []Tuple{normals[tri[0]], normals[tri[1]], normals[tri[2]]}
+			}
+			if uvs != nil {
+				faceUVs = []Tuple{uvs[tri[0]], uvs[tri[1]], uvs[tri[2]]}
+			}
+			of.addFace([]int{tri[0] + 1, tri[1] + 1, tri[2] + 1}, faceNormals, faceUVs)
+		}
+		of.finish()
+
+		of.DefaultGroup.Label = l.doc.Meshes[idx].Name
+		groups = append(groups, of.DefaultGroup)
+	}
+	return groups, nil
+}
+
+// Returns the vertex indices of each triangle, for a primitive's mode: 4 (TRIANGLES),
+// 5 (TRIANGLE_STRIP) or 6 (TRIANGLE_FAN).
+func gltfTriangles(mode int, indices []int) [][3]int {
+	triangles := [][3]int{}
+	switch mode {
+	case 4:
+		for i := 0; i+2 < len(indices); i += 3 {
+			triangles = append(triangles, [3]int{indices[i], indices[i+1], indices[i+2]})
+		}
+	case 5:
+		for i := 0; i+2 < len(indices); i++ {
+			if i%2 == 0 {
+				triangles = append(triangles, [3]int{indices[i], indices[i+1], indices[i+2]})
+			} else { // every other triangle is flipped, so they all face the same way
+				triangles = append(triangles, [3]int{indices[i+1], indices[i], indices[i+2]})
+			}
+		}
+	case 6:
+		for i := 1; i+1 < len(indices); i++ {
+			triangles = append(triangles, [3]int{indices[0], indices[i], indices[i+1]})
+		}
+	}
+	return triangles
+}
+
+// Returns the values of an accessor, e.g. [x, y, z] for each of a mesh's positions.
+// Normalized integers are converted to 0..1 (or -1..1 if they're signed).
+func (l *gltfLoader) accessor(idx int) ([][]float64, error) {
+	if err := checkGltfIndex("accessor", idx, len(l.doc.Accessors)); err != nil {
+		return nil, err
+	}
+	a := l.doc.Accessors[idx]
+	if a.Sparse != nil {
+		return nil, fmt.Errorf("glTF accessor %d is sparse, which isn't supported\n", idx)
+	}
+
+	components := map[string]int{"SCALAR": 1, "VEC2": 2, "VEC3": 3, "VEC4": 4, "MAT2": 4, "MAT3": 9, "MAT4": 16}[a.Type]
+	size := map[int]int{5120: 1, 5121: 1, 5122: 2, 5123: 2, 5125: 4, 5126: 4}[a.ComponentType]
+	if components == 0 || size == 0 {
+		return nil, fmt.Errorf("glTF accessor %d has an unknown type: %s of %d\n", idx, a.Type, a.ComponentType)
+	}
+
+	values := make([][]float64, a.Count)
+	for i := range values {
+		values[i] = make([]float64, components)
+	}
+	if a.BufferView == nil {
+		return values, nil // all zeros
+	}
+	if err := checkGltfIndex("buffer view", *a.BufferView, len(l.doc.BufferViews)); err != nil {
+		return nil, err
+	}
+	view := l.doc.BufferViews[*a.BufferView]
+	if err := checkGltfIndex("buffer", view.Buffer, len(l.buffers)); err != nil {
+		return nil, err
+	}
+	buffer := l.buffers[view.Buffer]
+	stride := view.ByteStride
+	if stride == 0 {
+		stride = components * size
+	}
+	start := view.ByteOffset + a.ByteOffset
+	if a.Count > 0 && (start < 0 || start+stride*(a.Count-1)+components*size > minInt(len(buffer), view.ByteOffset+view.ByteLength)) {
+		return nil, fmt.Errorf("glTF accessor %d is bigger than its buffer view\n", idx)
+	}
+
+	for i, value := range values {
+		for c := range value {
+			data := buffer[start+i*stride+c*size:]
+			switch a.ComponentType {
+			case 5120: // BYTE
+				value[c] = float64(int8(data[0]))
+				if a.Normalized {
+					value[c] = math.Max(value[c]/127, -1)
+				}
+			case 5121: // UNSIGNED_BYTE
+				value[c] = float64(data[0])
+				if a.Normalized {
+					value[c] /= 255
+				}
+			case 5122: // SHORT
+				value[c] = float64(int16(binary.LittleEndian.Uint16(data)))
+				if a.Normalized {
+					value[c] = math.Max(value[c]/32767, -1)
+				}
+			case 5123: // UNSIGNED_SHORT
+				value[c] = float64(binary.LittleEndian.Uint16(data))
+				if a.Normalized {
+					value[c] /= 65535
+				}
+			case 5125: // UNSIGNED_INT
+				value[c] = float64(binary.LittleEndian.Uint32(data))
+			case 5126: // FLOAT
+				value[c] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+			}
+		}
+	}
+	return values, nil
+}
+
+// Returns a material, or the default material (white, fully metallic and rough) for -1.
+func (l *gltfLoader) material(idx int) (*Material, error) {
+	m := NewPBRMaterial()
+	m.Metallic = 1
+	m.Roughness = 1
+	if idx < 0 {
+		return m, nil
+	}
+
+	gm := l.doc.Materials[idx]
+	m.Label = gm.Name
+	if pbr := gm.PbrMetallicRoughness; pbr != nil {
+		if len(pbr.BaseColorFactor) == 4 {
+			m.Color = NewColor(pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2])
+			if gm.AlphaMode == "BLEND" {
+				m.Transparency = 1 - pbr.BaseColorFactor[3]
+			}
+		}
+		if pbr.MetallicFactor != nil {
+			m.Metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			m.Roughness = *pbr.RoughnessFactor
+		}
+		if pbr.BaseColorTexture != nil {
+			p, err := l.texture(pbr.BaseColorTexture.Index)
+			if err != nil {
+				return nil, err
+			}
+			m.Pattern = p
+		}
+	}
+	return m, nil
+}
+
+// Returns a pattern for a texture, which is mapped with the triangles' UVs.
+func (l *gltfLoader) texture(idx int) (*Pattern, error) {
+	if p, found := l.textures[idx]; found {
+		return p, nil
+	}
+	if err := checkGltfIndex("texture", idx, len(l.doc.Textures)); err != nil {
+		return nil, err
+	}
+	texture := l.doc.Textures[idx]
+	if texture.Source == nil {
+		return nil, fmt.Errorf("glTF texture %d has no image\n", idx)
+	}
+	if err := checkGltfIndex("image", *texture.Source, len(l.doc.Images)); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	img := l.doc.Images[*texture.Source]
+	if img.BufferView != nil {
+		if err := checkGltfIndex("buffer view", *img.BufferView, len(l.doc.BufferViews)); err != nil {
+			return nil, err
+		}
+		view := l.doc.BufferViews[*img.BufferView]
+		if err := checkGltfIndex("buffer", view.Buffer, len(l.buffers)); err != nil {
+			return nil, err
+		}
+		if view.ByteOffset+view.ByteLength > len(l.buffers[view.Buffer]) {
+			return nil, fmt.Errorf("glTF image %d is bigger than its buffer\n", *texture.Source)
+		}
+		data = l.buffers[view.Buffer][view.ByteOffset : view.ByteOffset+view.ByteLength]
+	} else {
+		var err error
+		if data, err = l.loadURI(img.URI); err != nil {
+			return nil, err
+		}
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("glTF image %d: %w\n", *texture.Source, err)
+	}
+
+	filter, wrap := FilterBilinear, WrapRepeat
+	if texture.Sampler != nil {
+		if err := checkGltfIndex("sampler", *texture.Sampler, len(l.doc.Samplers)); err != nil {
+			return nil, err
+		}
+		sampler := l.doc.Samplers[*texture.Sampler]
+		if sampler.MagFilter == 9728 { // NEAREST
+			filter = FilterNearest
+		}
+		switch sampler.WrapS {
+		case 33071: // CLAMP_TO_EDGE
+			wrap = WrapClamp
+		case 33648: // MIRRORED_REPEAT
+			wrap = WrapMirror
+		}
+	}
+
+	p := NewShapeTextureMapPattern(NewFilteredUVImagePattern(NewCanvasFromImage(decoded), filter, wrap))
+	l.textures[idx] = p
+	return p, nil
+}
+
+// Returns a camera, positioned by the transform of its node, or nil if it's not a
+// perspective camera.
+func (l *gltfLoader) camera(idx int, transform Matrix) *Camera {
+	gc := l.doc.Cameras[idx]
+	if gc.Type != "perspective" || gc.Perspective == nil {
+		return nil
+	}
+
+	aspectRatio := gc.Perspective.AspectRatio
+	if aspectRatio <= 0 {
+		aspectRatio = 4.0 / 3
+	}
+	// NB FieldOfView is across the wider side of the canvas, but yfov is always vertical.
+	var c *Camera
+	if aspectRatio >= 1 {
+		fieldOfView := 2 * math.Atan(math.Tan(gc.Perspective.Yfov/2)*aspectRatio)
+		c = NewCamera(DefaultGltfCameraSize, int(math.Round(DefaultGltfCameraSize/aspectRatio)), fieldOfView)
+	} else {
+		c = NewCamera(int(math.Round(DefaultGltfCameraSize*aspectRatio)), DefaultGltfCameraSize, gc.Perspective.Yfov)
+	}
+
+	// glTF cameras look toward -z like this one, but with +x to the right instead of the left.
+	flip := NewScale(-1, 1, 1)
+	c.SetTransform(flip.Multiply(transform.Inverse()))
+	return c
+}
+
+// Returns a light, positioned by the transform of its node, with an Intensity of its color
+// (see scaleLights), or nil if its type is unknown.
+func (l *gltfLoader) light(idx int, transform Matrix) *AreaLight {
+	gl := l.doc.Extensions.Lights.Lights[idx]
+	intensity := Colors["White"]
+	if len(gl.Color) == 3 {
+		intensity = NewColor(gl.Color[0], gl.Color[1], gl.Color[2])
+	}
+
+	position := transform.MultiplyByTuple(NewPoint(0, 0, 0))
+	direction := transform.MultiplyByTuple(NewVector(0, 0, -1)).Normalized() // lights shine toward -z
+	switch gl.Type {
+	case "point":
+		return NewPointLight(position, intensity)
+	case "spot":
+		innerConeAngle, outerConeAngle := 0.0, math.Pi/4
+		if gl.Spot.InnerConeAngle != nil {
+			innerConeAngle = *gl.Spot.InnerConeAngle
+		}
+		if gl.Spot.OuterConeAngle != nil {
+			outerConeAngle = *gl.Spot.OuterConeAngle
+		}
+		return NewSpotLight(position, direction, innerConeAngle, outerConeAngle, intensity)
+	case "directional":
+		return NewPointLight(position.Subtract(direction.Multiply(gltfDirectionalLightDistance)), intensity)
+	}
+	return nil
+}
+
+// Scales the Intensity of each light by how bright it is at the center of the scene,
+// relative to the brightest light (see ParseGltf).
+func (l *gltfLoader) scaleLights(gf *GltfFile) {
+	center := NewPoint(0, 0, 0)
+	if bounds := gf.Scene.Bounds(); bounds.MinPoint.X <= bounds.MaxPoint.X {
+		center = bounds.MinPoint.Add(bounds.MaxPoint).Divide(2)
+	}
+
+	brightnesses := make([]float64, len(gf.Lights))
+	brightest := 0.0
+	for idx, light := range gf.Lights {
+		brightnesses[idx] = gf.LightIntensities[idx]
+		if l.lightFalloffs[idx] {
+			distance := math.Max(1, center.Subtract(light.Corner).Magnitude())
+			brightnesses[idx] /= distance * distance
+		}
+		brightest = math.Max(brightest, brightnesses[idx])
+	}
+	if brightest <= 0 {
+		return
+	}
+	for idx, light := range gf.Lights {
+		light.Intensity = light.Intensity.Multiply(brightnesses[idx] / brightest)
+	}
+}
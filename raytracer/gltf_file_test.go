@@ -0,0 +1,197 @@
+package raytracer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// Returns a buffer with 3 positions (floats) from 0, 3 colors (floats) from 36, and
+// 3 indices (unsigned shorts) from 72, for a triangle.
+func gltfTestBuffer() []byte {
+	var buffer bytes.Buffer
+	binary.Write(&buffer, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	binary.Write(&buffer, binary.LittleEndian, []float32{1, 0, 0, 0, 1, 0, 0, 0, 1})
+	binary.Write(&buffer, binary.LittleEndian, []uint16{0, 1, 2, 0}) // padded to 4 bytes
+	return buffer.Bytes()
+}
+
+const gltfTestBufferViews = `
+	"bufferViews": [
+		{ "buffer": 0, "byteOffset": 0, "byteLength": 36 },
+		{ "buffer": 0, "byteOffset": 36, "byteLength": 36 },
+		{ "buffer": 0, "byteOffset": 72, "byteLength": 6 }
+	],
+	"accessors": [
+		{ "bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3" },
+		{ "bufferView": 1, "componentType": 5126, "count": 3, "type": "VEC3" },
+		{ "bufferView": 2, "componentType": 5123, "count": 3, "type": "SCALAR" }
+	]`
+
+func TestParsingAGltfFile(t *testing.T) {
+	file := fmt.Sprintf(`{
+	"asset": { "version": "2.0" },
+	"scene": 0,
+	"scenes": [ { "nodes": [0, 2, 3] } ],
+	"nodes": [
+		{ "name": "parent", "translation": [0, 0, 5], "children": [1] },
+		{ "name": "triangle", "mesh": 0, "scale": [2, 2, 2] },
+		{ "camera": 0, "translation": [0, 0, 10] },
+		{ "translation": [0, 3, 0], "rotation": [-0.7071068, 0, 0, 0.7071068], "extensions": { "KHR_lights_punctual": { "light": 0 } } }
+	],
+	"meshes": [ { "primitives": [ { "attributes": { "POSITION": 0 }, "indices": 2, "material": 0 } ] } ],
+	"materials": [ { "name": "red", "pbrMetallicRoughness": { "baseColorFactor": [1, 0, 0, 1], "metallicFactor": 0, "roughnessFactor": 0.5 } } ],
+	"cameras": [ { "type": "perspective", "perspective": { "yfov": 0.8, "aspectRatio": 2, "znear": 0.1 } } ],
+	"extensions": { "KHR_lights_punctual": { "lights": [ { "type": "directional", "intensity": 2 } ] } },
+	"buffers": [ { "byteLength": 80, "uri": "data:application/octet-stream;base64,%s" } ],
+	%s
+}`, base64.StdEncoding.EncodeToString(gltfTestBuffer()), gltfTestBufferViews)
+
+	gf, err := ParseGltf([]byte(file), "", ObjOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqualInt(t, 3, len(gf.Scene.LocalShape.(Group).Children))
+	parent := gf.Scene.LocalShape.(Group).Children[0]
+	node := parent.LocalShape.(Group).Children[0]
+	tri := node.LocalShape.(Group).Children[0].LocalShape.(Group).Children[0]
+	assertEqualTuple(t, NewPoint(2, 0, 5), objectToWorldTransform(tri).MultiplyByTuple(tri.LocalShape.(*Triangle).P2))
+	assertEqualColor(t, Colors["Red"], tri.Material.Color)
+	assertEqualFloat64(t, 0.5, tri.Material.Roughness)
+	if tri.Material != gf.Materials[0] || tri.Material.Model != PBRModel {
+		t.Errorf("Expected the triangle to have the file's PBR material, but got %v", tri.Material)
+	}
+
+	assertEqualInt(t, 1, len(gf.Cameras))
+	c := gf.Cameras[0]
+	assertEqualInt(t, 640, c.HSize)
+	assertEqualInt(t, 320, c.VSize)
+	c.PixelSize()
+	assertEqualFloat64(t, math.Tan(0.4), c.HalfHeight)
+	r := c.RayForPixel(0, 160) // the left edge of the canvas
+	assertEqualTuple(t, NewPoint(0, 0, 10), r.Origin)
+	if r.Direction.X >= 0 || r.Direction.Z >= 0 {
+		t.Errorf("Expected the camera to look toward -z, with the left edge at -x, but got %v", r.Direction)
+	}
+
+	assertEqualInt(t, 1, len(gf.Lights))
+	assertEqualTuple(t, NewPoint(0, 3+gltfDirectionalLightDistance, 0), gf.Lights[0].Corner)
+	assertEqualColor(t, Colors["White"], gf.Lights[0].Intensity) // the brightest light
+	assertEqualFloat64(t, 2, gf.LightIntensities[0])
+}
+
+func TestParsingGltfLights(t *testing.T) {
+	file := fmt.Sprintf(`{
+	"asset": { "version": "2.0" },
+	"scenes": [ { "nodes": [0, 1, 2, 3] } ],
+	"nodes": [
+		{ "mesh": 0, "scale": [2, 2, 2] },
+		{ "translation": [1, 1, 3], "extensions": { "KHR_lights_punctual": { "light": 0 } } },
+		{ "extensions": { "KHR_lights_punctual": { "light": 1 } } },
+		{ "translation": [1, 1, 2], "extensions": { "KHR_lights_punctual": { "light": 2 } } }
+	],
+	"meshes": [ { "primitives": [ { "attributes": { "POSITION": 0 }, "indices": 2 } ] } ],
+	"extensions": { "KHR_lights_punctual": { "lights": [
+		{ "type": "point", "intensity": 54, "color": [1, 0.5, 0.5] },
+		{ "type": "directional", "intensity": 2 },
+		{ "type": "spot", "intensity": 12, "spot": { "innerConeAngle": 0.1, "outerConeAngle": 0.5 } }
+	] } },
+	"buffers": [ { "byteLength": 80, "uri": "data:application/octet-stream;base64,%s" } ],
+	%s
+}`, base64.StdEncoding.EncodeToString(gltfTestBuffer()), gltfTestBufferViews)
+
+	gf, err := ParseGltf([]byte(file), "", ObjOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scene's center is (1, 1, 0), so the point light is 54/3^2 = 6 lux there (the
+	// brightest), the directional light is 2 lux and the spot light is 12/2^2 = 3 lux.
+	assertEqualInt(t, 3, len(gf.Lights))
+	assertEqualColor(t, NewColor(1, 0.5, 0.5), gf.Lights[0].Intensity)
+	assertEqualColor(t, NewColor(1.0/3, 1.0/3, 1.0/3), gf.Lights[1].Intensity)
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), gf.Lights[2].Intensity)
+	assertEqualFloat64(t, 54, gf.LightIntensities[0])
+	assertEqualFloat64(t, 2, gf.LightIntensities[1])
+	assertEqualFloat64(t, 12, gf.LightIntensities[2])
+
+	expectedSpotLight := SpotLight{NewPoint(1, 1, 2), NewVector(0, 0, -1), 0.1, 0.5}
+	if spotLight, isSpotLight := gf.Lights[2].LocalLight.(SpotLight); !isSpotLight || !spotLight.localIsEqualTo(expectedSpotLight) {
+		t.Errorf("Expected %v, but got %v", expectedSpotLight, gf.Lights[2].LocalLight)
+	}
+}
+
+func TestParsingAGltfMeshThatSeveralNodesUse(t *testing.T) {
+	file := fmt.Sprintf(`{
+	"asset": { "version": "2.0" },
+	"scenes": [ { "nodes": [0, 1] } ],
+	"nodes": [ { "mesh": 0 }, { "mesh": 0, "translation": [5, 0, 0] } ],
+	"meshes": [ { "primitives": [ { "attributes": { "POSITION": 0 }, "indices": 2 } ] } ],
+	"buffers": [ { "byteLength": 80, "uri": "data:application/octet-stream;base64,%s" } ],
+	%s
+}`, base64.StdEncoding.EncodeToString(gltfTestBuffer()), gltfTestBufferViews)
+
+	gf, err := ParseGltf([]byte(file), "", ObjOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := gf.Scene.LocalShape.(Group).Children
+	tri1 := nodes[0].LocalShape.(Group).Children[0].LocalShape.(Group).Children[0]
+	tri2 := nodes[1].LocalShape.(Group).Children[0].LocalShape.(Group).Children[0]
+	if tri1 == tri2 || tri1.LocalShape != tri2.LocalShape {
+		t.Errorf("Expected the nodes to have their own copies of the same triangle, but got %v and %v", tri1, tri2)
+	}
+	assertEqualTuple(t, NewPoint(6, 0, 0), objectToWorldTransform(tri2).MultiplyByTuple(tri2.LocalShape.(*Triangle).P2))
+	assertEqualTuple(t, NewPoint(1, 0, 0), objectToWorldTransform(tri1).MultiplyByTuple(tri1.LocalShape.(*Triangle).P2))
+}
+
+func TestParsingAGlbFileWithVertexColors(t *testing.T) {
+	buffer := gltfTestBuffer()
+	json := []byte(`{
+	"asset": { "version": "2.0" },
+	"scenes": [ { "nodes": [0] } ],
+	"nodes": [ { "mesh": 0 } ],
+	"meshes": [ { "primitives": [ { "attributes": { "POSITION": 0, "COLOR_0": 1 }, "indices": 2 } ] } ],
+	"buffers": [ { "byteLength": 80 } ],` + gltfTestBufferViews + "}  ") // padded to 4 bytes
+
+	var file bytes.Buffer
+	file.WriteString("glTF")
+	binary.Write(&file, binary.LittleEndian, []uint32{2, uint32(12 + 8 + len(json) + 8 + len(buffer))})
+	binary.Write(&file, binary.LittleEndian, []uint32{uint32(len(json)), 0x4E4F534A})
+	file.Write(json)
+	binary.Write(&file, binary.LittleEndian, []uint32{uint32(len(buffer)), 0x004E4942})
+	file.Write(buffer)
+
+	gf, err := ParseGltf(file.Bytes(), "", ObjOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tri := gf.Scene.LocalShape.(Group).Children[0].LocalShape.(Group).Children[0].LocalShape.(Group).Children[0]
+	assertEqualFloat64(t, 1, tri.Material.Metallic)
+	assertEqualColor(t, NewColor(0.5, 0.5, 0), tri.Material.Pattern.PatternAtShape(tri, NewPoint(0.5, 0, 0)))
+}
+
+func TestParsingAnInvalidGltfFile(t *testing.T) {
+	testCases := []string{
+		`not json`,
+		`{ "scenes": [ { "nodes": [1] } ], "nodes": [ {} ] }`,
+		`{ "scenes": [ { "nodes": [0] } ], "nodes": [ { "children": [0] } ] }`,
+		`{ "scenes": [ { "nodes": [0] } ], "nodes": [ { "mesh": 0 } ], "meshes": [ { "primitives": [ { "attributes": { "POSITION": 0 } } ] } ],
+		  "accessors": [ { "bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3" } ],
+		  "bufferViews": [ { "buffer": 0, "byteLength": 12 } ], "buffers": [ { "uri": "data:application/octet-stream;base64,AAAAAAAAAAAAAAAA" } ] }`,
+		"glTF\x01\x00\x00\x00\x0c\x00\x00\x00",
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			if _, err := ParseGltf([]byte(tc), "", ObjOptions{}); err == nil {
+				t.Errorf("Expected an error parsing %q", tc)
+			}
+		})
+	}
+}
@@ -6,12 +6,20 @@ import (
 )
 
 type GradientPattern struct {
-	A Color
-	B Color
+	A        Color
+	B        Color
+	PatternA *Pattern // if set, used instead of A
+	PatternB *Pattern // if set, used instead of B
 }
 
 func NewGradientPattern(a, b Color) *Pattern {
-	return NewPattern(GradientPattern{a, b})
+	return NewPattern(GradientPattern{A: a, B: b})
+}
+
+// Returns a gradient pattern of two other patterns, which each keep their own
+// transformation (relative to this pattern's).
+func NewGradientPatternFromPatterns(a, b *Pattern) *Pattern {
+	return NewPattern(GradientPattern{PatternA: a, PatternB: b})
 }
 
 func (p GradientPattern) String() string {
@@ -25,9 +33,11 @@ func (p GradientPattern) String() string {
 // ... [a blending function] is a function that takes two values and interpolates the values between them ...
 func (p GradientPattern) LocalPatternAt(point Tuple) Color {
 	// color(p, ca, cb) = ca + (cb − ca) ∗ (px − floor(px))
-	distance := p.B.Subtract(p.A)
+	a := colorOrPatternAt(p.A, p.PatternA, point)
+	b := colorOrPatternAt(p.B, p.PatternB, point)
+	distance := b.Subtract(a)
 	fraction := point.X - math.Floor(point.X)
-	return p.A.Add(distance.Multiply(fraction))
+	return a.Add(distance.Multiply(fraction))
 }
 
 func (p GradientPattern) LocalUVPatternAt(u, v float64) Color {
@@ -35,9 +45,11 @@ func (p GradientPattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (gp GradientPattern) localIsEqualTo(gp2 PatternInterface) bool {
-	gp2Pattern := gp2.(*GradientPattern)
+	gp2Pattern := gp2.(GradientPattern)
 	if !gp.A.IsEqualTo(gp2Pattern.A) || !gp.B.IsEqualTo(gp2Pattern.B) {
 		return false
+	} else if !patternsAreEqual(gp.PatternA, gp2Pattern.PatternA) || !patternsAreEqual(gp.PatternB, gp2Pattern.PatternB) {
+		return false
 	}
 	return true
 }
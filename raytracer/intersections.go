@@ -10,22 +10,38 @@ type Intersection struct {
 	Object *Shape
 	U      float64 // identifies where on 2D triangle that an intersection occurred, relative to corners.
 	V      float64 // identifies where on 2D triangle that an intersection occurred, relative to corners.
+	TexU   float64 // the shape's own texture coordinates at the intersection (see UVShapeInterface), set by Shape.Intersect.
+	TexV   float64 // the shape's own texture coordinates at the intersection (see UVShapeInterface), set by Shape.Intersect.
+	HasUV  bool    // does the shape have its own texture coordinates?
+	Face   int     // for a Mesh: the index of the triangle that was hit
 }
 
 type Intersections []*Intersection
 
 type Computation struct {
-	Time       float64 // the moment (in time units) at which the intersection happened
-	Object     *Shape  // the object that was intersected
-	Point      Tuple   // the point where intersection happened
-	OverPoint  Tuple   // the Point value adjusted slightly to avoid "raytracer acne"
-	UnderPoint Tuple   //
-	EyeV       Tuple   // the vector from eye to the Point
-	NormalV    Tuple   // the normal on the object at the given Point
-	ReflectV   Tuple   // the vector of reflection
-	Inside     bool    // was the intersection inside the object?
-	N1         float64 // refractive index of object being exited at ray-object intersection
-	N2         float64 // refractive index of object being entered at ray-object intersection
+	Time       float64      // the moment (in time units) at which the intersection happened
+	Object     *Shape       // the object that was intersected
+	Material   *Material    // the object's material, with its parameter patterns evaluated at the Point
+	Point      Tuple        // the point where intersection happened
+	OverPoint  Tuple        // the Point value adjusted slightly to avoid "raytracer acne"
+	UnderPoint Tuple        //
+	EyeV       Tuple        // the vector from eye to the Point
+	NormalV    Tuple        // the normal on the object at the given Point
+	ReflectV   Tuple        // the vector of reflection
+	Inside     bool         // was the intersection inside the object?
+	N1         float64      // refractive index of object being exited at ray-object intersection
+	N2         float64      // refractive index of object being entered at ray-object intersection
+	N1Material *Material    // material of object being exited (nil for vacuum), for per-wavelength indices
+	N2Material *Material    // material of object being entered (nil for vacuum), for per-wavelength indices
+	Occlusion  float64      // how much ambient light reaches the Point, from 0 (none) to 1 (all). See AmbientOcclusion.
+	Footprint  float64      // how wide the ray is at the Point (see Ray.Footprint)
+	Spread     float64      // how much wider the ray gets per unit of distance (see Ray.Spread)
+	TexU       float64      // the shape's own texture coordinates at the Point (see Intersection.TexU)
+	TexV       float64      // the shape's own texture coordinates at the Point (see Intersection.TexV)
+	HasUV      bool         // does the shape have its own texture coordinates?
+	Jitter     *PixelJitter // the ray's own sequences for jittering random samples, if any (see Ray.Jitter)
+	Glossy     bool         // was the ray already spread out by a rough surface? (see Ray.Glossy)
+	Channel    int          // the color channel the ray carries, if dispersion split it up (see Ray.Channel)
 }
 
 func NewIntersection(t float64, obj *Shape) *Intersection {
@@ -68,7 +84,7 @@ func (xs Intersections) String() string {
 func (is *Intersections) Hit(checkingForShadows bool) *Intersection {
 	var minIntersection *Intersection
 	for _, intersection := range *is {
-		if intersection.Time > EPSILON && (!checkingForShadows || intersection.Object.Shadows) { // NB the book uses 0, but I had to use this instead to fix the minute difference that broke in TestTheRefractedColorWithARefractedRay's Sphere#LocalIntersect's i2 calcluation
+		if intersection.Time > EPSILON && (!checkingForShadows || (intersection.Object.Shadows && intersection.Object.Material.Volume == nil)) { // NB the book uses 0, but I had to use this instead to fix the minute difference that broke in TestTheRefractedColorWithARefractedRay's Sphere#LocalIntersect's i2 calcluation
 			if minIntersection == nil || minIntersection.IsEqualTo(intersection) || intersection.Time < minIntersection.Time {
 				minIntersection = intersection
 			}
@@ -78,6 +94,39 @@ func (is *Intersections) Hit(checkingForShadows bool) *Intersection {
 	return minIntersection
 }
 
+// Returns the Volume (see Material.Volume) that a ray is traveling through right
+// before the hit, or nil if it isn't inside one. Every intersection with a volume's
+// surface, in time order, either enters or exits it. A nil hit means the ray
+// travels on forever. NB the shapes are tracked rather than their materials, because
+// several shapes (e.g. overlapping clouds) can share one material.
+func (is Intersections) VolumeBefore(hit *Intersection) *Volume {
+	insideOf := []*Shape{}
+	for _, intersection := range is {
+		if hit != nil && intersection.Time >= hit.Time {
+			break
+		}
+		if intersection.Object.Material.Volume == nil {
+			continue
+		}
+		idx := -1
+		for i, container := range insideOf {
+			if container == intersection.Object {
+				idx = i
+			}
+		}
+		if idx == -1 { // enter
+			insideOf = append(insideOf, intersection.Object)
+		} else { // exit
+			insideOf = append(insideOf[:idx], insideOf[idx+1:]...)
+		}
+	}
+
+	if len(insideOf) == 0 {
+		return nil
+	}
+	return insideOf[len(insideOf)-1].Material.Volume
+}
+
 // r:  the ray that hit the intersection
 // xs: "... the collection of all intersections, which can tell you where the hit is relative to the rest of the intersections ...""
 func (i *Intersection) PrepareComputations(r *Ray, xs ...*Intersection) *Computation {
@@ -94,6 +143,12 @@ func (i *Intersection) PrepareComputations(r *Ray, xs ...*Intersection) *Computa
 	c.ReflectV = r.Direction.Reflect(c.NormalV)            // TODO after negating the normal, if necessary
 	c.OverPoint = c.Point.Add(c.NormalV.Multiply(EPSILON)) // to avoid "raytracer acne" with shadows
 	c.UnderPoint = c.Point.Subtract(c.NormalV.Multiply(EPSILON))
+	c.Occlusion = 1.0
+	c.Footprint = r.Footprint + r.Spread*c.Time*r.Direction.Magnitude()
+	c.Spread = r.Spread
+	c.Jitter, c.Glossy = r.Jitter, r.Glossy
+	c.Channel = r.Channel
+	c.TexU, c.TexV, c.HasUV = i.TexU, i.TexV, i.HasUV
 
 	if c.NormalV.Dot(c.EyeV) < 0 {
 		c.Inside = true
@@ -102,6 +157,15 @@ func (i *Intersection) PrepareComputations(r *Ray, xs ...*Intersection) *Computa
 		c.Inside = false
 	}
 
+	c.Material = c.Object.Material.At(c.Object, c.OverPoint)
+
+	// Bumps only change how the surface is shaded, so they're applied after the
+	// (geometric) normal has been used for the over/under points and the inside test.
+	if perturbation := c.Object.Material.NormalPerturbation; perturbation != nil {
+		c.NormalV = perturbation.PerturbNormal(c.Object, c.Point, c.NormalV)
+		c.ReflectV = r.Direction.Reflect(c.NormalV)
+	}
+
 	visitedShapes := []*Shape{}
 	for _, intersection := range xs {
 		isHit := intersection.IsEqualTo(i) // is this intersection the hit?
@@ -110,7 +174,8 @@ func (i *Intersection) PrepareComputations(r *Ray, xs ...*Intersection) *Computa
 			if len(visitedShapes) == 0 {
 				c.N1 = 1.0
 			} else {
-				c.N1 = visitedShapes[len(visitedShapes)-1].Material.RefractiveIndex
+				c.N1Material = visitedShapes[len(visitedShapes)-1].Material
+				c.N1 = c.N1Material.RefractiveIndex
 			}
 		}
 
@@ -134,15 +199,37 @@ func (i *Intersection) PrepareComputations(r *Ray, xs ...*Intersection) *Computa
 			if len(visitedShapes) == 0 {
 				c.N2 = 1.0
 			} else {
-				c.N2 = visitedShapes[len(visitedShapes)-1].Material.RefractiveIndex
+				c.N2Material = visitedShapes[len(visitedShapes)-1].Material
+				c.N2 = c.N2Material.RefractiveIndex
 			}
 			break
 		}
 	}
 
+	// A ray that only carries one channel bends by that channel's refractive indices.
+	if c.Channel != AllChannels {
+		c.N1 = refractiveIndicesOf(c.N1Material)[c.Channel-1]
+		c.N2 = refractiveIndicesOf(c.N2Material)[c.Channel-1]
+	}
+
 	return c
 }
 
+// Returns a new ray that continues on from the hit (e.g. a reflection), and is as
+// wide as the ray that hit it (and jittered with the same sequences).
+func (c *Computation) spawnRay(origin, direction Tuple) *Ray {
+	r := NewRay(origin, direction)
+	r.Footprint, r.Spread = c.Footprint, c.Spread
+	r.Jitter, r.Glossy = c.Jitter, c.Glossy
+	r.Channel = c.Channel
+	return r
+}
+
+// Returns whether either side of the hit has dispersion, i.e. refracts each color differently.
+func (c *Computation) isDispersive() bool {
+	return (c.N1Material != nil && c.N1Material.Abbe > 0) || (c.N2Material != nil && c.N2Material.Abbe > 0)
+}
+
 // Schlick's equation is an approximation of Fresnel's.
 // Returns the "reflectance", which represents what fraction of the light is reflected at the given hit.
 // TODO rename "SchlickReflectance"?
@@ -1,6 +1,7 @@
 package raytracer
 
 import (
+	"fmt"
 	"math"
 	"testing"
 )
@@ -241,6 +242,27 @@ func TestFindingN1AndN2AtVariousIntersections(t *testing.T) {
 	}
 }
 
+func TestFindingN1AndN2ForARayThatCarriesOneChannel(t *testing.T) {
+	shape := NewGlassSphere()
+	shape.Material.Abbe = 20
+	xs := Intersections{NewIntersection(4, shape), NewIntersection(6, shape)}
+	indices := shape.Material.RefractiveIndices()
+
+	for idx, channel := range []int{RedChannel, GreenChannel, BlueChannel} {
+		t.Run(fmt.Sprintf("channel %d", channel), func(t *testing.T) {
+			r := NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1))
+			r.Channel = channel
+
+			comps := xs[0].PrepareComputations(r, xs...)
+			assertEqualFloat64(t, 1.0, comps.N1)
+			assertEqualFloat64(t, indices[idx], comps.N2)
+			comps = xs[1].PrepareComputations(r, xs...)
+			assertEqualFloat64(t, indices[idx], comps.N1)
+			assertEqualFloat64(t, 1.0, comps.N2)
+		})
+	}
+}
+
 func TestTheUnderPointIsOffsetBelowTheSurface(t *testing.T) {
 	shape := NewGlassSphere()
 	shape.SetTransform(NewTranslation(0, 0, 1))
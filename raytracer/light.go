@@ -2,15 +2,28 @@ package raytracer
 
 import "fmt"
 
+// LightInterface is implemented by light sources that aren't rectangular (e.g. SphereLight,
+// DiskLight, SpotLight), which are stored in AreaLight.LocalLight.
+type LightInterface interface {
+	// Maps a sample (su, sv in [0, 1)) to a point on the light's surface, as seen from point p.
+	LocalPointOnLight(su, sv float64, p Tuple) Tuple
+	// How much of its intensity the light shines toward point p, from 0 (e.g. behind a
+	// one-sided light) to 1.
+	localIntensityAt(p Tuple) float64
+	localIsEqualTo(LightInterface) bool
+	localType() string
+}
+
 type AreaLight struct {
-	Corner    Tuple   // corner: position of one corner of the light source
-	UVec      Tuple   // direction+length of the u edge
-	USteps    float64 // how many points are sampled along u edge. More steps = less banding, but with jittering it becomes noisier.
-	VVec      Tuple   // direction+length of the v edge
-	VSteps    float64 // how many points are sampled along v edge. More steps = less banding, but with jittering it becomes noisier.
-	Intensity Color   // intensity of the light
-	Samples   float64
-	Jitter    *Sequence
+	Corner     Tuple   // corner: position of one corner of the light source
+	UVec       Tuple   // direction+length of the u edge
+	USteps     float64 // how many points are sampled along u edge. More steps = less banding, but with jittering it becomes noisier.
+	VVec       Tuple   // direction+length of the v edge
+	VSteps     float64 // how many points are sampled along v edge. More steps = less banding, but with jittering it becomes noisier.
+	Intensity  Color   // intensity of the light
+	Samples    float64
+	Jitter     *Sequence
+	LocalLight LightInterface // the shape of the light, or nil for a rectangle (Corner/UVec/VVec)
 }
 
 // Returns a flat, rectangular light source -- composed of cells -- that casts a soft shadow.
@@ -51,7 +64,7 @@ func NewPointLight(position Tuple, intensity Color) *AreaLight {
 
 func (al *AreaLight) String() string {
 	return fmt.Sprintf(
-		"AreaLight(\nCorner: %v\nUVec: %v\nUSteps: %v\nVVec: %v\nVSteps: %v\nIntensity: %v\nSamples: %v\nJitter: %v\n)",
+		"AreaLight(\nCorner: %v\nUVec: %v\nUSteps: %v\nVVec: %v\nVSteps: %v\nIntensity: %v\nSamples: %v\nJitter: %v\nLocalLight: %v\n)",
 		al.Corner,
 		al.UVec,
 		al.USteps,
@@ -60,6 +73,7 @@ func (al *AreaLight) String() string {
 		al.Intensity,
 		al.Samples,
 		al.Jitter,
+		al.LocalLight,
 	)
 }
 
@@ -82,21 +96,48 @@ func (al *AreaLight) IsEqualTo(al2 *AreaLight) bool {
 		return false
 	} else if !al.Jitter.IsEqualTo(*al2.Jitter) {
 		return false
+	} else if (al.LocalLight == nil) != (al2.LocalLight == nil) {
+		return false
+	} else if al.LocalLight != nil {
+		if al.LocalLight.localType() != al2.LocalLight.localType() {
+			return false
+		}
+		return al.LocalLight.localIsEqualTo(al2.LocalLight)
 	}
 	return true
 }
 
 func (al AreaLight) IntensityAt(p Tuple, w *World) float64 {
+	scale := 1.0
+	if al.LocalLight != nil {
+		if scale = al.LocalLight.localIntensityAt(p); scale <= 0 {
+			return 0.0
+		}
+	}
 	total := 0.0
+	for _, lightPosition := range al.SamplePoints(p) {
+		if !w.IsShadowed(lightPosition, p) {
+			total += 1.0
+		}
+	}
+	return scale * total / al.Samples
+}
+
+// Returns one point on the light per cell (USteps * VSteps), as seen from point p.
+func (al *AreaLight) SamplePoints(p Tuple) []Tuple {
+	samples := make([]Tuple, 0, int(al.Samples))
 	for v := 0.0; v < al.VSteps; v++ {
 		for u := 0.0; u < al.USteps; u++ {
-			lightPosition := al.PointOnLight(u, v)
-			if !w.IsShadowed(lightPosition, p) {
-				total += 1.0
+			if al.LocalLight == nil {
+				samples = append(samples, al.PointOnLight(u, v))
+			} else {
+				su := (u + al.Jitter.Next()) / al.USteps
+				sv := (v + al.Jitter.Next()) / al.VSteps
+				samples = append(samples, al.LocalLight.LocalPointOnLight(su, sv, p))
 			}
 		}
 	}
-	return total / al.Samples
+	return samples
 }
 
 // Returns the real point on the area light based on the u/v coordinates.
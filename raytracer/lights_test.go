@@ -2,6 +2,7 @@ package raytracer
 
 import (
 	"fmt"
+	"math"
 	"testing"
 )
 
@@ -135,6 +136,127 @@ func TestTheAreaLightWithJitteredSamples(t *testing.T) {
 	}
 }
 
+func TestCreatingASphereLight(t *testing.T) {
+	light := NewSphereLight(NewPoint(0, 5, 0), 0.5, 4, 2, NewColor(1, 1, 1))
+
+	assertEqualTuple(t, NewPoint(0, 5, 0), light.Corner)
+	assertEqualFloat64(t, 8.0, light.Samples)
+	assertEqualString(t, "SphereLight", light.LocalLight.localType())
+}
+
+func TestPointsOnASphereLightAreOnTheVisibleSideOfTheSphere(t *testing.T) {
+	center := NewPoint(0, 5, 0)
+	light := NewSphereLight(center, 0.5, 4, 4, NewColor(1, 1, 1))
+	p := NewPoint(0, 0, 0)
+
+	samples := light.SamplePoints(p)
+	assertEqualInt(t, 16, len(samples))
+	for idx, sample := range samples {
+		t.Run(fmt.Sprintf("samples[%d]", idx), func(t *testing.T) {
+			assertEqualFloat64(t, 0.5, sample.Subtract(center).Magnitude())
+			assert(t, sample.Subtract(center).Dot(p.Subtract(center)) >= 0)
+		})
+	}
+}
+
+func TestPointsOnASphereLightFromInsideCoverTheWholeSphere(t *testing.T) {
+	light := SphereLight{NewPoint(0, 0, 0), 2}
+
+	assertEqualTuple(t, NewPoint(0, 0, 2), light.LocalPointOnLight(0, 0, NewPoint(0, 0, 0)))
+	assertEqualTuple(t, NewPoint(0, 0, -2), light.LocalPointOnLight(1, 0, NewPoint(0, 0, 0)))
+}
+
+func TestFindingPointsOnADiskLight(t *testing.T) {
+	light := DiskLight{NewPoint(0, 3, 0), NewVector(0, -1, 0), 2}
+	testCases := []struct {
+		su     float64
+		sv     float64
+		radius float64
+	}{
+		{0, 0, 0},
+		{0.25, 0.3, 1},
+		{1, 0.5, 2},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			pt := light.LocalPointOnLight(tc.su, tc.sv, NewPoint(0, 0, 0))
+			assertEqualFloat64(t, 3, pt.Y)
+			assertEqualFloat64(t, tc.radius, pt.Subtract(light.Center).Magnitude())
+		})
+	}
+}
+
+func TestADiskLightOnlyShinesInFrontOfIt(t *testing.T) {
+	w := NewWorld()
+	light := NewDiskLight(NewPoint(0, 3, 0), NewVector(0, -1, 0), 1, 2, 2, NewColor(1, 1, 1))
+	testCases := []struct {
+		Point  Tuple
+		Result float64
+	}{
+		{NewPoint(0, 0, 0), 1.0},
+		{NewPoint(5, 2.9, 0), 1.0},
+		{NewPoint(5, 3, 0), 0.0}, // i.e. in the disk's plane
+		{NewPoint(0, 6, 0), 0.0},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualFloat64(t, tc.Result, light.IntensityAt(tc.Point, w))
+		})
+	}
+}
+
+func TestTheSpotLightIntensityFunction(t *testing.T) {
+	w := NewWorld()
+	light := NewSpotLight(NewPoint(0, 3, 0), NewVector(0, -1, 0), math.Pi/8, math.Pi/4, NewColor(1, 1, 1))
+	halfway := (math.Cos(math.Pi/8) + math.Cos(math.Pi/4)) / 2 // the cosine of an angle between the cones
+	testCases := []struct {
+		Point  Tuple
+		Result float64
+	}{
+		{NewPoint(0, 0, 0), 1.0},
+		{NewPoint(3*math.Tan(math.Pi/10), 0, 0), 1.0}, // inside the inner cone
+		{NewPoint(3*math.Tan(math.Acos(halfway)), 0, 0), 0.25},
+		{NewPoint(3, 0, 0), 0.0}, // on the outer cone
+		{NewPoint(0, 6, 0), 0.0},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualFloat64(t, tc.Result, light.IntensityAt(tc.Point, w))
+		})
+	}
+}
+
+func TestTheSphereLightIntensityFunction(t *testing.T) {
+	w := DefaultWorld()
+	light := NewSphereLight(NewPoint(0, 0, -5), 0.5, 2, 2, NewColor(1, 1, 1))
+	testCases := []struct {
+		Point  Tuple
+		Result float64
+	}{
+		{NewPoint(0, 0, 2), 0.0},
+		{NewPoint(0, 0, -2), 1.0},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualFloat64(t, tc.Result, light.IntensityAt(tc.Point, w))
+		})
+	}
+}
+
+func TestComparingLightsOfDifferentShapes(t *testing.T) {
+	sphereLight := NewSphereLight(NewPoint(0, 5, 0), 1, 2, 2, NewColor(1, 1, 1))
+	diskLight := NewDiskLight(NewPoint(0, 5, 0), NewVector(0, -1, 0), 1, 2, 2, NewColor(1, 1, 1))
+	pointLight := NewPointLight(NewPoint(0, 5, 0), NewColor(1, 1, 1))
+	spotLight := NewSpotLight(NewPoint(0, 5, 0), NewVector(0, -1, 0), 0.5, 1, NewColor(1, 1, 1))
+
+	assert(t, sphereLight.IsEqualTo(NewSphereLight(NewPoint(0, 5, 0), 1, 2, 2, NewColor(1, 1, 1))))
+	assert(t, !sphereLight.IsEqualTo(diskLight))
+	assert(t, !diskLight.IsEqualTo(pointLight))
+	assert(t, spotLight.IsEqualTo(NewSpotLight(NewPoint(0, 5, 0), NewVector(0, -1, 0), 0.5, 1, NewColor(1, 1, 1))))
+	assert(t, !spotLight.IsEqualTo(NewSpotLight(NewPoint(0, 5, 0), NewVector(0, -1, 0), 0.5, 0.8, NewColor(1, 1, 1))))
+	assert(t, !spotLight.IsEqualTo(pointLight))
+}
+
 /////////////
 // Benchmarks
 /////////////
@@ -168,3 +290,11 @@ func BenchmarkAreaLightMethodIntensityAt(b *testing.B) {
 		light.IntensityAt(NewPoint(0, 0, -2), w)
 	}
 }
+
+func BenchmarkSphereLightMethodIntensityAt(b *testing.B) {
+	w := DefaultWorld()
+	light := NewSphereLight(NewPoint(0, 0, -5), 0.5, 2, 2, NewColor(1, 1, 1))
+	for i := 0; i < b.N; i++ {
+		light.IntensityAt(NewPoint(0, 0, -2), w)
+	}
+}
@@ -0,0 +1,51 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// MarblePattern is veins of A and B along the x axis (one pair per 2 units),
+// made wavy with Turbulence.
+type MarblePattern struct {
+	A          Color
+	B          Color
+	Turbulence float64 // how wavy the veins are
+	Octaves    int     // how detailed the waviness is
+}
+
+func NewMarblePattern(a, b Color) *Pattern {
+	return NewPattern(MarblePattern{A: a, B: b, Turbulence: 2, Octaves: 4})
+}
+
+func (p MarblePattern) String() string {
+	return fmt.Sprintf("MarblePattern( A: %v B: %v Turbulence: %v Octaves: %v )", p.A, p.B, p.Turbulence, p.Octaves)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p MarblePattern) LocalPatternAt(point Tuple) Color {
+	turbulence := Turbulence(point.X, point.Y, point.Z, p.Octaves)
+	fraction := (math.Sin(math.Pi*(point.X+p.Turbulence*turbulence)) + 1) / 2
+	return p.A.Add(p.B.Subtract(p.A).Multiply(fraction))
+}
+
+func (p MarblePattern) LocalUVPatternAt(u, v float64) Color {
+	return Colors["Black"]
+}
+
+func (p MarblePattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(MarblePattern)
+	if !p.A.IsEqualTo(p2Pattern.A) || !p.B.IsEqualTo(p2Pattern.B) {
+		return false
+	}
+	return p.Turbulence == p2Pattern.Turbulence && p.Octaves == p2Pattern.Octaves
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p MarblePattern) localType() string {
+	return "MarblePattern"
+}
@@ -5,17 +5,42 @@ import (
 	"math"
 )
 
+// The lighting models that a Material can use.
+const (
+	PhongModel = "phong" // the book's Phong reflection (Ambient, Diffuse, Specular, Shininess)
+	PBRModel   = "pbr"   // physically based GGX microfacets (Metallic, Roughness, RefractiveIndex)
+)
+
 type Material struct {
-	Label           string
-	Color           Color
-	Ambient         Color
-	Diffuse         Color
-	Specular        Color
-	Shininess       float64
-	Pattern         *Pattern
-	Reflective      float64
-	Transparency    float64
-	RefractiveIndex float64 // Vacuum=1, Water=1.333, Glass=1.52, Diamond=2.42
+	Label              string
+	Model              string // PhongModel (or empty) or PBRModel
+	Color              Color
+	Ambient            Color
+	Diffuse            Color
+	Specular           Color
+	Shininess          float64
+	Metallic           float64 // for PBRModel: 0 for dielectrics (e.g. plastic), 1 for metals
+	Roughness          float64 // 0 for a perfectly smooth surface, 1 for a completely rough one: it blurs reflections and refractions (see World.GlossySamples), and for PBRModel, highlights
+	Pattern            *Pattern
+	NormalPerturbation NormalPerturbation // optionally makes the surface look bumpy, e.g. a NoiseBump, PatternBump or NormalMap
+	Reflective         float64
+	Transparency       float64
+	RefractiveIndex    float64 // Vacuum=1, Water=1.333, Glass=1.52, Diamond=2.42
+	Abbe               float64 // dispersion; 0 for none, otherwise lower is more. Water=55.7, Crown Glass=59, Flint Glass=36, Diamond=55.3
+	Absorption         Color   // how much of each channel transparent materials absorb per unit of distance, e.g. to tint thick glass
+	Volume             *Volume // if set, the shape is filled with this medium instead of having a visible surface
+
+	// Optionally vary the parameters above over the surface, like Pattern does for Color.
+	// The colors of patterns for float64 parameters are averaged (i.e. black=0, white=1),
+	// except for ShininessPattern, which is multiplied by Shininess.
+	AmbientPattern      *Pattern
+	DiffusePattern      *Pattern
+	SpecularPattern     *Pattern
+	ShininessPattern    *Pattern
+	MetallicPattern     *Pattern
+	RoughnessPattern    *Pattern
+	ReflectivePattern   *Pattern
+	TransparencyPattern *Pattern
 }
 
 // Beware: use this instead of Material{}, for Material{} without all the args will throw errors when rendering.
@@ -33,10 +58,22 @@ func DefaultMaterial() *Material {
 	}
 }
 
+// Returns a material that uses the PBRModel: a smooth white dielectric with the
+// refractive index of most plastics and glass (i.e. 4% reflectance head-on).
+func NewPBRMaterial() *Material {
+	m := DefaultMaterial()
+	m.Model = PBRModel
+	m.Roughness = 0.5
+	m.RefractiveIndex = 1.5
+	return m
+}
+
 func (m *Material) IsEqualTo(m2 *Material) bool {
 	// TODO add check for Pattern equality too
 	if !m.Color.IsEqualTo(m2.Color) {
 		return false
+	} else if m.Model != m2.Model {
+		return false
 	} else if !m.Ambient.IsEqualTo(m2.Ambient) {
 		return false
 	} else if !m.Diffuse.IsEqualTo(m2.Diffuse) {
@@ -45,32 +82,131 @@ func (m *Material) IsEqualTo(m2 *Material) bool {
 		return false
 	} else if m.Shininess != m2.Shininess {
 		return false
+	} else if m.Metallic != m2.Metallic {
+		return false
+	} else if m.Roughness != m2.Roughness {
+		return false
 	} else if m.Reflective != m2.Reflective {
 		return false
 	} else if m.Transparency != m2.Transparency {
 		return false
 	} else if m.RefractiveIndex != m2.RefractiveIndex {
 		return false
+	} else if m.Abbe != m2.Abbe {
+		return false
+	} else if !m.Absorption.IsEqualTo(m2.Absorption) {
+		return false
+	} else if !m.Volume.IsEqualTo(m2.Volume) {
+		return false
 	}
 	return true
 }
 
 func (m *Material) String() string {
 	return fmt.Sprintf(
-		"Material(\n  Label: %v\n  Color: %v\n  Ambient: %v\n  Diffuse: %v\n  Specular: %v\n  Shininess: %v\n  Pattern: %v\n  Reflective: %v\n  Transparency: %v\n  ReflectiveIndex: %v\n)",
+		"Material(\n  Label: %v\n  Model: %v\n  Color: %v\n  Ambient: %v\n  Diffuse: %v\n  Specular: %v\n  Shininess: %v\n  Metallic: %v\n  Roughness: %v\n  Pattern: %v\n  NormalPerturbation: %v\n  Reflective: %v\n  Transparency: %v\n  ReflectiveIndex: %v\n  Abbe: %v\n  Absorption: %v\n  Volume: %v\n)",
 		m.Label,
+		m.Model,
 		m.Color,
 		m.Ambient,
 		m.Diffuse,
 		m.Specular,
 		m.Shininess,
+		m.Metallic,
+		m.Roughness,
 		m.Pattern,
+		m.NormalPerturbation,
 		m.Reflective,
 		m.Transparency,
 		m.RefractiveIndex,
+		m.Abbe,
+		m.Absorption,
+		m.Volume,
 	)
 }
 
+// The wavelengths (in nanometers) of the Fraunhofer C, d and F lines, which the
+// red, green and blue channels are traced at when a material has dispersion.
+var dispersionWavelengths = [3]float64{656.3, 587.6, 486.1}
+
+// Returns the refractive indices of the material for the red, green and blue channels.
+// RefractiveIndex is taken to be the index at the d line (green) and Abbe describes
+// how much it varies with wavelength, fitted with Cauchy's equation: n = A + B/λ^2.
+// Without dispersion (Abbe=0) all three are just RefractiveIndex.
+func (m *Material) RefractiveIndices() [3]float64 {
+	if m.Abbe <= 0 {
+		return [3]float64{m.RefractiveIndex, m.RefractiveIndex, m.RefractiveIndex}
+	}
+	c, d, f := dispersionWavelengths[0], dispersionWavelengths[1], dispersionWavelengths[2]
+	b := (m.RefractiveIndex - 1) / (m.Abbe * (1/(f*f) - 1/(c*c)))
+	a := m.RefractiveIndex - b/(d*d)
+
+	indices := [3]float64{}
+	for idx, wavelength := range dispersionWavelengths {
+		indices[idx] = a + b/(wavelength*wavelength)
+	}
+	return indices
+}
+
+// Returns the material's color at the given point, from its Pattern if it has one.
+func (m *Material) ColorAt(obj *Shape, point Tuple) Color {
+	if m.Pattern != nil {
+		return m.Pattern.PatternAtShape(obj, point)
+	}
+	return m.Color
+}
+
+// Same as ColorAt(), but for the point and footprint of a computation (so textures
+// can be filtered).
+func (m *Material) colorAtComputation(c *Computation) Color {
+	if m.Pattern != nil {
+		return m.Pattern.patternAtComputation(c)
+	}
+	return m.Color
+}
+
+// Returns the material with the parameter patterns (e.g. ReflectivePattern) evaluated
+// at the given point on obj, or the material itself if it doesn't have any.
+func (m *Material) At(obj *Shape, point Tuple) *Material {
+	if m.AmbientPattern == nil && m.DiffusePattern == nil && m.SpecularPattern == nil &&
+		m.ShininessPattern == nil && m.MetallicPattern == nil && m.RoughnessPattern == nil &&
+		m.ReflectivePattern == nil && m.TransparencyPattern == nil {
+		return m
+	}
+
+	scalarAt := func(p *Pattern) float64 {
+		c := p.PatternAtShape(obj, point)
+		return (c.Red + c.Green + c.Blue) / 3
+	}
+
+	resolved := *m
+	if m.AmbientPattern != nil {
+		resolved.Ambient = m.AmbientPattern.PatternAtShape(obj, point)
+	}
+	if m.DiffusePattern != nil {
+		resolved.Diffuse = m.DiffusePattern.PatternAtShape(obj, point)
+	}
+	if m.SpecularPattern != nil {
+		resolved.Specular = m.SpecularPattern.PatternAtShape(obj, point)
+	}
+	if m.ShininessPattern != nil {
+		resolved.Shininess = m.Shininess * scalarAt(m.ShininessPattern)
+	}
+	if m.MetallicPattern != nil {
+		resolved.Metallic = scalarAt(m.MetallicPattern)
+	}
+	if m.RoughnessPattern != nil {
+		resolved.Roughness = scalarAt(m.RoughnessPattern)
+	}
+	if m.ReflectivePattern != nil {
+		resolved.Reflective = scalarAt(m.ReflectivePattern)
+	}
+	if m.TransparencyPattern != nil {
+		resolved.Transparency = scalarAt(m.TransparencyPattern)
+	}
+	return &resolved
+}
+
 // Calculates the lighting for a given point and material, based on Phong reflection.
 // Phone reflection model:
 //   * Ambient reflection:  background lighting; a constant value.
@@ -79,29 +215,29 @@ func (m *Material) String() string {
 //      										and eye vectors. Intensity is controlled by "shininess".
 // Inensity: 0.0 = in shadow, 1.0 = not in shadow.
 func (m *Material) Lighting(obj *Shape, light *AreaLight, point Tuple, eyeVector, normalVector Tuple, intensity float64) Color {
-	var baseColor, ambient, specular, diffuse Color
+	c := &Computation{Object: obj, Material: m, OverPoint: point, EyeV: eyeVector, NormalV: normalVector, Occlusion: 1.0}
+	return m.lightingAt(c, light, intensity)
+}
 
-	if m.Pattern != nil {
-		baseColor = m.Pattern.PatternAtShape(obj, point)
-	} else {
-		baseColor = m.Color
+// Same as Lighting(), but for the Object/OverPoint/EyeV/NormalV of a computation, and
+// with the ambient contribution scaled by its Occlusion.
+func (m *Material) lightingAt(c *Computation, light *AreaLight, intensity float64) Color {
+	if m.Model == PBRModel {
+		return m.pbrLightingAt(c, light, intensity)
 	}
 
+	var ambient, specular, diffuse Color
+	point, eyeVector, normalVector := c.OverPoint, c.EyeV, c.NormalV
+
+	baseColor := m.colorAtComputation(c)
 	effectiveColor := baseColor.MultiplyColor(light.GetIntensity()) // Combine the surface color with the light's color/intensity
 	ambient = effectiveColor.MultiplyColor(m.Ambient)               // Compute the ambient contribution
-
-	samples := []Tuple{}
-	// TODO: can we memoize and abstract this out, with the shared code in IntensityAt()?
-	for v := 0.0; v < light.VSteps; v++ {
-		for u := 0.0; u < light.USteps; u++ {
-			samples = append(samples, light.PointOnLight(u, v))
-		}
-	}
+	ambient = ambient.Multiply(c.Occlusion)
 
 	sum := Colors["Black"]
 
 	// Loop through each of the points in the area light
-	for _, sample := range samples {
+	for _, sample := range light.SamplePoints(point) {
 		// The direction to the light source
 		lightVector := sample.Subtract(point).Normalized()
 		// The cosine of the angle between the light vector and the normal vector. Negative means light is on other side of surface.
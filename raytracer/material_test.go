@@ -235,6 +235,137 @@ func TestLightingSamplesTheAreaLight(t *testing.T) {
 
 }
 
+func TestRefractiveIndicesWithoutDispersion(t *testing.T) {
+	m := DefaultMaterial()
+	m.RefractiveIndex = 1.5
+
+	assertEqualBool(t, true, [3]float64{1.5, 1.5, 1.5} == m.RefractiveIndices())
+}
+
+func TestRefractiveIndicesWithDispersion(t *testing.T) {
+	m := DefaultMaterial()
+	m.RefractiveIndex = 1.5
+	m.Abbe = 40
+	indices := m.RefractiveIndices()
+
+	// Green is the RefractiveIndex, and blue bends the most.
+	assertEqualFloat64(t, 1.5, indices[1])
+	assert(t, indices[0] < indices[1] && indices[1] < indices[2])
+	// The Abbe number is (n_d - 1) / (n_F - n_C).
+	assertEqualFloat64(t, 40, (indices[1]-1)/(indices[2]-indices[0]))
+}
+
+func TestPBRLightingWithEyeAndLightAboveARoughDielectric(t *testing.T) {
+	obj := NewSphere()
+	mat := NewPBRMaterial()
+	mat.Roughness = 1
+	pos := NewPoint(0, 0, 0)
+
+	eyeV := NewVector(0, 0, -1)
+	normalV := NewVector(0, 0, -1)
+	light := NewPointLight(NewPoint(0, 0, -10), Colors["White"])
+
+	// 4% is reflected (specular), and the remaining 96% is diffused.
+	actual := mat.Lighting(obj, light, pos, eyeV, normalV, 1.0)
+	expected := NewColor(0.1+0.97, 0.1+0.97, 0.1+0.97)
+
+	assertEqualColor(t, expected, actual)
+}
+
+func TestPBRLightingWithEyeAndLightAboveARoughMetal(t *testing.T) {
+	obj := NewSphere()
+	mat := NewPBRMaterial()
+	mat.Color = NewColor(1, 0.5, 0)
+	mat.Metallic = 1
+	mat.Roughness = 1
+	pos := NewPoint(0, 0, 0)
+
+	eyeV := NewVector(0, 0, -1)
+	normalV := NewVector(0, 0, -1)
+	light := NewPointLight(NewPoint(0, 0, -10), Colors["White"])
+
+	// Metals have no diffuse, and their reflection is tinted by their color.
+	actual := mat.Lighting(obj, light, pos, eyeV, normalV, 1.0)
+	expected := NewColor(0.35, 0.175, 0)
+
+	assertEqualColor(t, expected, actual)
+}
+
+func TestPBRLightingWithLightBehindSurface(t *testing.T) {
+	obj := NewSphere()
+	mat := NewPBRMaterial()
+	pos := NewPoint(0, 0, 0)
+
+	eyeV := NewVector(0, 0, -1)
+	normalV := NewVector(0, 0, -1)
+	light := NewPointLight(NewPoint(0, 0, 10), Colors["White"])
+
+	actual := mat.Lighting(obj, light, pos, eyeV, normalV, 1.0)
+	expected := NewColor(0.1, 0.1, 0.1)
+
+	assertEqualColor(t, expected, actual)
+}
+
+func TestPBRLightingHighlightIsSharperWhenSmoother(t *testing.T) {
+	obj := NewSphere()
+	pos := NewPoint(0, 0, 0)
+	normalV := NewVector(0, 0, -1)
+	light := NewPointLight(NewPoint(0, 0, -10), Colors["White"])
+	testCases := []struct {
+		eyeV                    Tuple
+		smoothBrighterThanRough bool
+	}{
+		{NewVector(0, 0, -1), true},                            // in the highlight
+		{NewVector(0, math.Sqrt(2)/2, -math.Sqrt(2)/2), false}, // away from the highlight
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			smooth, rough := NewPBRMaterial(), NewPBRMaterial()
+			smooth.Metallic, rough.Metallic = 1, 1
+			smooth.Roughness, rough.Roughness = 0.1, 0.9
+
+			smoothColor := smooth.Lighting(obj, light, pos, tc.eyeV, normalV, 1.0)
+			roughColor := rough.Lighting(obj, light, pos, tc.eyeV, normalV, 1.0)
+			assertEqualBool(t, tc.smoothBrighterThanRough, smoothColor.Red > roughColor.Red)
+		})
+	}
+}
+
+func TestMaterialAtWithoutParameterPatterns(t *testing.T) {
+	obj := NewSphere()
+
+	assertEqualBool(t, true, obj.Material == obj.Material.At(obj, NewPoint(0, 0, -1)))
+}
+
+func TestMaterialAtWithParameterPatterns(t *testing.T) {
+	obj := NewPlane()
+	obj.Material.Shininess = 100
+	// Reflective only on the white squares.
+	obj.Material.ReflectivePattern = NewCheckerPattern(Colors["White"], Colors["Black"])
+	obj.Material.DiffusePattern = NewStripePattern(NewColor(1, 0.5, 0), Colors["Black"])
+	obj.Material.ShininessPattern = NewSolidPattern(NewColor(0.5, 0.5, 0.5))
+
+	testCases := []struct {
+		point      Tuple
+		reflective float64
+		diffuse    Color
+	}{
+		{NewPoint(0.5, 0, 0.5), 1, NewColor(1, 0.5, 0)},
+		{NewPoint(1.5, 0, 0.5), 0, Colors["Black"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			m := obj.Material.At(obj, tc.point)
+
+			assertEqualFloat64(t, tc.reflective, m.Reflective)
+			assertEqualColor(t, tc.diffuse, m.Diffuse)
+			assertEqualFloat64(t, 50, m.Shininess)
+			// The original material is left alone.
+			assertEqualFloat64(t, 0, obj.Material.Reflective)
+		})
+	}
+}
+
 /////////////
 // Benchmarks
 /////////////
@@ -0,0 +1,412 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// How many triangles a leaf of a Mesh's BVH can have.
+const meshLeafSize = 4
+
+// Mesh is a lot of triangles in one shape, which share their points (and normals and UVs),
+// e.g. from an OBJ file with ObjOptions.Meshes. Unlike a Group of Triangles, the triangles
+// don't each have their own Shape (with transforms and a material), which uses a lot less
+// memory and is faster to intersect: the mesh has its own BVH (bounding volume hierarchy),
+// and rays are only transformed once, into the mesh's object space.
+type Mesh struct {
+	Points    []Tuple
+	Triangles [][3]int // the indices in Points of each triangle's points, in the same order as a Triangle's
+
+	// Optional: the normals at each triangle's points (for smooth shading, like SmoothTriangle),
+	// with the indices in Normals of each triangle's normals. Otherwise triangles are flat.
+	Normals       []Tuple
+	NormalIndices [][3]int
+
+	// Optional: the texture coordinates of each triangle's points (X=u, Y=v), with the indices in
+	// UVs of each triangle's UVs. Otherwise the UVs are the barycentric coordinates, like a Triangle's.
+	UVs       []Tuple
+	UVIndices [][3]int
+
+	nodes []meshNode // the BVH, starting with its root
+	order []int      // the indices of the Triangles, in the order that the BVH's leaves have them
+}
+
+// A node of a Mesh's BVH: either a leaf with some triangles, or a node with two children.
+type meshNode struct {
+	bounds      BoundingBox
+	left, right int // the children's indices in nodes
+	start       int // the leaf's first triangle in order
+	count       int // how many triangles the leaf has, or 0 if it's not a leaf
+}
+
+// Returns a mesh of triangles, where each triangle is the indices of its points in points.
+// Set the Mesh's Normals and UVs (and their indices) afterwards for smooth shading or textures.
+func NewMesh(points []Tuple, triangles [][3]int) *Shape {
+	return NewMeshWithOptions(points, triangles, BVHOptions{})
+}
+
+// Same as NewMesh, but with options for building its BVH, e.g. to split it with the surface
+// area heuristic (see DivideWithOptions). Only Split and Bins apply: leaves have up to 4
+// triangles, or more if SAHSplit estimates that splitting them isn't cheaper.
+func NewMeshWithOptions(points []Tuple, triangles [][3]int, options BVHOptions) *Shape {
+	m := Mesh{Points: points, Triangles: triangles}
+	m.buildBVH(options)
+	return NewShape(&m)
+}
+
+func (m *Mesh) String() string {
+	return fmt.Sprintf(
+		"Mesh( Points: %d Triangles: %d Normals: %d UVs: %d Nodes: %d )",
+		len(m.Points),
+		len(m.Triangles),
+		len(m.Normals),
+		len(m.UVs),
+		len(m.nodes),
+	)
+}
+
+// Returns the points of a triangle, and its edges from P1 (the same as Triangle's E1 and E2).
+func (m *Mesh) trianglePoints(face int) (Tuple, Tuple, Tuple) {
+	tri := m.Triangles[face]
+	p1 := m.Points[tri[0]]
+	return p1, m.Points[tri[1]].Subtract(p1), m.Points[tri[2]].Subtract(p1)
+}
+
+// Builds the BVH, by splitting the triangles at the middle of the longest axis of their
+// centroids, or with the surface area heuristic (see BVHOptions).
+func (m *Mesh) buildBVH(options BVHOptions) {
+	m.nodes = []meshNode{}
+	m.order = make([]int, len(m.Triangles))
+	if len(m.Triangles) == 0 {
+		return // NB not even a leaf, since a leaf without triangles looks like a node with children
+	}
+	centroids := make([]Tuple, len(m.Triangles))
+	for face, tri := range m.Triangles {
+		m.order[face] = face
+		centroids[face] = m.Points[tri[0]].Add(m.Points[tri[1]]).Add(m.Points[tri[2]]).Divide(3)
+	}
+	m.buildNode(0, len(m.order), centroids, options)
+}
+
+// Adds a node for the triangles in order[start:end], returning its index.
+func (m *Mesh) buildNode(start, end int, centroids []Tuple, options BVHOptions) int {
+	idx := len(m.nodes)
+	m.nodes = append(m.nodes, meshNode{})
+
+	bounds := NullBoundingBox()
+	centroidBounds := NullBoundingBox()
+	for _, face := range m.order[start:end] {
+		tri := m.Triangles[face]
+		bounds.AddPoints(m.Points[tri[0]], m.Points[tri[1]], m.Points[tri[2]])
+		centroidBounds.AddPoints(centroids[face])
+	}
+	if end-start <= meshLeafSize {
+		m.nodes[idx] = meshNode{bounds: bounds, start: start, count: end - start}
+		return idx
+	}
+
+	var mid int
+	if options.Split == SAHSplit {
+		if mid = m.partitionSAH(start, end, centroids, options.Bins); mid < 0 {
+			m.nodes[idx] = meshNode{bounds: bounds, start: start, count: end - start}
+			return idx
+		}
+	} else {
+		mid = m.partitionMidpoint(start, end, centroids, centroidBounds)
+	}
+
+	left := m.buildNode(start, mid, centroids, options)
+	right := m.buildNode(mid, end, centroids, options)
+	m.nodes[idx] = meshNode{bounds: bounds, left: left, right: right}
+	return idx
+}
+
+// Sorts the triangles in order[start:end] by which side of the middle of the longest axis
+// of their centroids they're on, returning where the right side starts.
+func (m *Mesh) partitionMidpoint(start, end int, centroids []Tuple, centroidBounds BoundingBox) int {
+	axis := longestAxis(centroidBounds)
+	middle := (tupleAxis(centroidBounds.MinPoint, axis) + tupleAxis(centroidBounds.MaxPoint, axis)) / 2
+	mid := start
+	for i := start; i < end; i++ {
+		if tupleAxis(centroids[m.order[i]], axis) < middle {
+			m.order[i], m.order[mid] = m.order[mid], m.order[i]
+			mid++
+		}
+	}
+	if mid == start || mid == end { // e.g. all of the centroids are the same
+		mid = (start + end) / 2
+	}
+	return mid
+}
+
+// Sorts the triangles in order[start:end] by which side of the split that the surface
+// area heuristic estimates is cheapest they're on (see findSAHSplit), returning where the
+// right side starts, or -1 if not splitting them is cheaper.
+func (m *Mesh) partitionSAH(start, end int, centroids []Tuple, bins int) int {
+	if bins <= 0 {
+		bins = DefaultSAHBins
+	}
+	boxes := make([]BoundingBox, end-start)
+	faceCentroids := make([]Tuple, end-start)
+	for i, face := range m.order[start:end] {
+		tri := m.Triangles[face]
+		boxes[i] = NullBoundingBox()
+		boxes[i].AddPoints(m.Points[tri[0]], m.Points[tri[1]], m.Points[tri[2]])
+		faceCentroids[i] = centroids[face]
+	}
+	split := findSAHSplit(boxes, faceCentroids, bins)
+	if split.axis < 0 {
+		return -1
+	}
+
+	mid := start
+	for i := start; i < end; i++ {
+		if split.isLeft(centroids[m.order[i]]) {
+			m.order[i], m.order[mid] = m.order[mid], m.order[i]
+			mid++
+		}
+	}
+	return mid
+}
+
+// Returns stats about the mesh's BVH (see Shape.BVHStats).
+func (m *Mesh) bvhStats() BVHStats {
+	if len(m.nodes) == 0 {
+		return BVHStats{}
+	}
+	stats := BVHStats{Groups: len(m.nodes), MinLeafSize: math.MaxInt}
+	rootArea := m.nodes[0].bounds.SurfaceArea()
+
+	var visit func(idx, depth int)
+	visit = func(idx, depth int) {
+		node := m.nodes[idx]
+		stats.Depth = maxInt(stats.Depth, depth)
+		weight := 1.0 // NB if the mesh is flat, its nodes are all weighted the same
+		if rootArea > 0 {
+			weight = node.bounds.SurfaceArea() / rootArea
+		}
+		stats.SAHCost += weight * (sahTraversalCost + sahIntersectionCost*float64(node.count))
+		if node.count == 0 {
+			visit(node.left, depth+1)
+			visit(node.right, depth+1)
+			return
+		}
+		stats.Leaves += 1
+		stats.MinLeafSize = minInt(stats.MinLeafSize, node.count)
+		stats.MaxLeafSize = maxInt(stats.MaxLeafSize, node.count)
+	}
+	visit(0, 1)
+	stats.MeanLeafSize = float64(len(m.Triangles)) / float64(stats.Leaves)
+	return stats
+}
+
+// Returns 0, 1 or 2 for whichever of the box's x, y or z sides is longest.
+func longestAxis(b BoundingBox) int {
+	size := b.MaxPoint.Subtract(b.MinPoint)
+	if size.X >= size.Y && size.X >= size.Z {
+		return 0
+	} else if size.Y >= size.Z {
+		return 1
+	}
+	return 2
+}
+
+// Returns the x, y or z of a tuple, for axis 0, 1 or 2.
+func tupleAxis(t Tuple, axis int) float64 {
+	switch axis {
+	case 0:
+		return t.X
+	case 1:
+		return t.Y
+	default:
+		return t.Z
+	}
+}
+
+// Intersects the ray with one of the triangles, the same way as Triangle does, returning
+// the time and the u and v of the intersection, and whether there is one.
+func (m *Mesh) intersectTriangle(r *Ray, face int) (float64, float64, float64, bool) {
+	p1, e1, e2 := m.trianglePoints(face)
+	directionCrossE2 := r.Direction.Cross(e2)
+	determinant := directionCrossE2.Dot(e1)
+	if equalFloat64s(determinant, 0) { // ray is parallel to triangle
+		return 0, 0, 0, false
+	}
+
+	f := 1.0 / determinant
+	p1ToOrigin := r.Origin.Subtract(p1)
+	u := f * p1ToOrigin.Dot(directionCrossE2)
+	if u < EPSILON || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	originCrossE1 := p1ToOrigin.Cross(e1)
+	v := f * r.Direction.Dot(originCrossE1)
+	if v < EPSILON || (u+v) > 1 {
+		return 0, 0, 0, false
+	}
+	return f * e2.Dot(originCrossE1), u, v, true
+}
+
+// Returns the triangle that a point is on (or closest to, if it's just off the mesh), and
+// the point's u and v on it, e.g. for texture lookups without an intersection. Returns -1
+// if the point isn't near any triangle.
+func (m *Mesh) triangleAt(localPoint Tuple) (int, float64, float64) {
+	const tolerance = 0.001
+	closest, closestU, closestV := -1, 0.0, 0.0
+	closestDistance := math.Inf(1)
+
+	stack := []int{0}
+	for len(stack) > 0 && len(m.nodes) > 0 {
+		node := m.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		min, max := node.bounds.MinPoint, node.bounds.MaxPoint
+		if localPoint.X < min.X-tolerance || localPoint.Y < min.Y-tolerance || localPoint.Z < min.Z-tolerance ||
+			localPoint.X > max.X+tolerance || localPoint.Y > max.Y+tolerance || localPoint.Z > max.Z+tolerance {
+			continue
+		}
+		if node.count == 0 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+
+		for _, face := range m.order[node.start : node.start+node.count] {
+			p1, e1, e2 := m.trianglePoints(face)
+			toPoint := localPoint.Subtract(p1)
+			d11, d12, d22 := e1.Dot(e1), e1.Dot(e2), e2.Dot(e2)
+			d1p, d2p := toPoint.Dot(e1), toPoint.Dot(e2)
+			denominator := d11*d22 - d12*d12
+			if math.Abs(denominator) < EPSILON*EPSILON {
+				continue // degenerate
+			}
+			u := (d22*d1p - d12*d2p) / denominator
+			v := (d11*d2p - d12*d1p) / denominator
+
+			// The distance to the closest point on the triangle (roughly, if it's outside the
+			// triangle), from clamping u and v to it.
+			cu, cv := math.Max(0, u), math.Max(0, v)
+			if cu+cv > 1 {
+				cu, cv = cu/(cu+cv), cv/(cu+cv)
+			}
+			distance := p1.Add(e1.Multiply(cu)).Add(e2.Multiply(cv)).Subtract(localPoint).Magnitude()
+			if distance < closestDistance {
+				closest, closestU, closestV, closestDistance = face, u, v, distance
+			}
+		}
+	}
+	if closestDistance > tolerance {
+		return -1, 0, 0
+	}
+	return closest, closestU, closestV
+}
+
+// Returns the hit's triangle, and its u and v on it, or the triangle at the point if there's no hit.
+func (m *Mesh) hitTriangle(localPoint Tuple, hit *Intersection) (int, float64, float64) {
+	if hit != nil {
+		return hit.Face, hit.U, hit.V
+	}
+	return m.triangleAt(localPoint)
+}
+
+/////////////////////////
+// ShapeInterface methods
+/////////////////////////
+
+func (m *Mesh) LocalBounds() BoundingBox {
+	if len(m.nodes) == 0 {
+		return NullBoundingBox()
+	}
+	return m.nodes[0].bounds
+}
+
+// Intersects the ray with the triangles in the leaves of the BVH that it passes through.
+// The intersections have the triangle's index in Triangles as their Face.
+func (m *Mesh) LocalIntersect(r *Ray, shape *Shape) Intersections {
+	xs := Intersections{}
+	if len(m.nodes) == 0 {
+		return xs
+	}
+
+	stack := []int{0}
+	for len(stack) > 0 {
+		node := m.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if !node.bounds.Intersects(r) {
+			continue
+		}
+		if node.count == 0 {
+			stack = append(stack, node.left, node.right)
+			continue
+		}
+
+		for _, face := range m.order[node.start : node.start+node.count] {
+			if time, u, v, hit := m.intersectTriangle(r, face); hit {
+				i := NewIntersectionWithUV(time, shape, u, v)
+				i.Face = face
+				xs = append(xs, i)
+			}
+		}
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i].Time < xs[j].Time })
+	return xs
+}
+
+// Returns the normal of the hit's triangle, interpolated from its Normals if it has them.
+// Without a hit, it's the normal of the triangle at the point.
+func (m *Mesh) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
+	face, u, v := m.hitTriangle(localPoint, hit)
+	if face < 0 {
+		return NewVector(0, 1, 0) // not on the mesh
+	}
+
+	if m.NormalIndices != nil {
+		ni := m.NormalIndices[face]
+		return m.Normals[ni[1]].Multiply(u).
+			Add(m.Normals[ni[2]].Multiply(v)).
+			Add(m.Normals[ni[0]].Multiply(1 - u - v))
+	}
+	_, e1, e2 := m.trianglePoints(face)
+	return e2.Cross(e1).Normalized() // same as Triangle.Normal
+}
+
+// Fulfills UVShapeInterface, by interpolating the UVs of the triangle's points.
+func (m *Mesh) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	face, u, v := m.hitTriangle(localPoint, hit)
+	if face < 0 {
+		return 0, 0
+	} else if m.UVIndices == nil {
+		return u, v
+	}
+
+	uvi := m.UVIndices[face]
+	uv1, uv2, uv3 := m.UVs[uvi[0]], m.UVs[uvi[1]], m.UVs[uvi[2]]
+	w := 1 - u - v
+	return uv1.X*w + uv2.X*u + uv3.X*v, uv1.Y*w + uv2.Y*u + uv3.Y*v
+}
+
+func (m *Mesh) localIsEqualTo(m2 ShapeInterface) bool {
+	m2Mesh := m2.(*Mesh)
+	if len(m.Points) != len(m2Mesh.Points) || len(m.Triangles) != len(m2Mesh.Triangles) {
+		return false
+	}
+	for idx, p := range m.Points {
+		if !p.IsEqualTo(m2Mesh.Points[idx]) {
+			return false
+		}
+	}
+	for idx, tri := range m.Triangles {
+		if tri != m2Mesh.Triangles[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *Mesh) localString() string {
+	return m.String()
+}
+
+func (m *Mesh) localType() string {
+	return "Mesh"
+}
@@ -0,0 +1,296 @@
+package raytracer
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// How many segments round shapes (spheres, cylinders and cones) are divided into around
+// their circumference when they're tessellated.
+const DefaultTessellationSegments = 32
+
+// MeshTriangle is a triangle in world space, e.g. for exporting shapes to OBJ or STL files.
+// Its points are in the same order as a Triangle's, i.e. its Normal() faces outwards.
+type MeshTriangle struct {
+	P1 Tuple
+	P2 Tuple
+	P3 Tuple
+}
+
+// Returns the normal of the triangle, the same way as Triangle does.
+func (t MeshTriangle) Normal() Tuple {
+	normal := t.P3.Subtract(t.P1).Cross(t.P2.Subtract(t.P1))
+	if normal.Magnitude() < EPSILON {
+		return normal
+	}
+	return normal.Normalized()
+}
+
+func (t MeshTriangle) String() string {
+	return fmt.Sprintf("MeshTriangle( P1: %v P2: %v P3: %v )", t.P1, t.P2, t.P3)
+}
+
+// Returns the triangles of a shape (and its children) in world space, with the transforms
+// of the shape and its parents baked in. Spheres, cylinders and cones are divided into
+// the given number of segments around, cubes into 2 triangles per face, and CSG shapes
+// keep the triangles of their children that are on their surface (without splitting
+// triangles where the children meet, so more segments give smoother seams).
+// Infinite shapes (planes, and cylinders and cones without a Minimum and Maximum) are skipped.
+func Tessellate(s *Shape, segments int) []MeshTriangle {
+	switch localShape := s.LocalShape.(type) {
+	case Group:
+		triangles := []MeshTriangle{}
+		for _, child := range localShape.Children {
+			triangles = append(triangles, Tessellate(child, segments)...)
+		}
+		return triangles
+	case Csg:
+		left := Tessellate(localShape.Left, segments)
+		right := Tessellate(localShape.Right, segments)
+
+		triangles := []MeshTriangle{}
+		for _, t := range left {
+			inr := isInside(localShape.Right, t.centroid())
+			if IntersectionAllowed(localShape.Operation, true, false, inr) {
+				triangles = append(triangles, t)
+			}
+		}
+		for _, t := range right {
+			inl := isInside(localShape.Left, t.centroid())
+			if IntersectionAllowed(localShape.Operation, false, inl, false) {
+				if localShape.Operation == "difference" {
+					t.P2, t.P3 = t.P3, t.P2 // the right shape's surface faces into the left one now
+				}
+				triangles = append(triangles, t)
+			}
+		}
+		return triangles
+	}
+
+	local := tessellateLocal(s.LocalShape, segments)
+
+	// Transform the triangles to world space, keeping them facing outwards if the
+	// transform mirrors them (e.g. scaling by -1).
+	transform := objectToWorldTransform(s)
+	mirrored := transform.Determinant() < 0
+	triangles := make([]MeshTriangle, len(local))
+	for idx, t := range local {
+		triangles[idx] = MeshTriangle{
+			transform.MultiplyByTuple(t.P1),
+			transform.MultiplyByTuple(t.P2),
+			transform.MultiplyByTuple(t.P3),
+		}
+		if mirrored {
+			triangles[idx].P2, triangles[idx].P3 = triangles[idx].P3, triangles[idx].P2
+		}
+	}
+	return triangles
+}
+
+// Returns the transform from a shape's object space to world space, i.e. its transform
+// and all of its parents'.
+func objectToWorldTransform(s *Shape) Matrix {
+	transform := s.Transform
+	for parent := s.Parent; parent != nil; parent = parent.Parent {
+		transform = parent.Transform.Multiply(transform)
+	}
+	return transform
+}
+
+// Is the point (in world space) inside the shape? Casts a ray from it and counts how many
+// times it crosses the shape's surface. NB Intersect only applies the shape's own transform,
+// so the ray starts from the point in its parent's space.
+func isInside(s *Shape, worldPoint Tuple) bool {
+	point := worldPoint
+	if s.Parent != nil {
+		point = s.Parent.WorldToObject(worldPoint)
+	}
+	// NB an arbitrary direction, so the ray doesn't line up with edges of cubes or tessellations.
+	r := NewRay(point, NewVector(0.5773, 0.5774, 0.5775))
+	crossings := 0
+	for _, x := range s.Intersect(r) {
+		if x.Time > EPSILON {
+			crossings += 1
+		}
+	}
+	return crossings%2 == 1
+}
+
+func (t MeshTriangle) centroid() Tuple {
+	return t.P1.Add(t.P2).Add(t.P3).Divide(3)
+}
+
+// Returns the triangles of a shape in its object space, facing outwards.
+func tessellateLocal(si ShapeInterface, segments int) []MeshTriangle {
+	triangles := []MeshTriangle{}
+	// Adds a triangle, in the order that makes it face the same way as outward.
+	addFacing := func(outward, p1, p2, p3 Tuple) {
+		t := MeshTriangle{p1, p2, p3}
+		if t.Normal().Dot(outward) < 0 {
+			t.P2, t.P3 = t.P3, t.P2
+		}
+		triangles = append(triangles, t)
+	}
+	// Adds a triangle, facing the same way as the shape's normal.
+	add := func(p1, p2, p3 Tuple) {
+		centroid := MeshTriangle{p1, p2, p3}.centroid()
+		addFacing(si.LocalNormalAt(centroid, nil), p1, p2, p3)
+	}
+	addQuad := func(p1, p2, p3, p4 Tuple) {
+		add(p1, p2, p3)
+		add(p1, p3, p4)
+	}
+	// Returns a point on a circle around the y axis. NB the last point is the same as the
+	// first (not just close to it), so that the triangles on either side share it.
+	around := func(idx int, radius, y float64) Tuple {
+		theta := 2 * math.Pi * float64(idx%segments) / float64(segments)
+		return NewPoint(radius*math.Cos(theta), y, radius*math.Sin(theta))
+	}
+	// Adds the side of a cylinder or cone between y1 and y2 (with radii r1 and r2), and their caps.
+	// NB caps face down and up explicitly, since a cone's normals are only right for caps within a radius of 1.
+	addSide := func(r1, y1, r2, y2 float64, closed bool) {
+		for idx := 0; idx < segments; idx++ {
+			switch {
+			case r1 < EPSILON:
+				add(around(idx, r1, y1), around(idx, r2, y2), around(idx+1, r2, y2))
+			case r2 < EPSILON:
+				add(around(idx, r1, y1), around(idx+1, r1, y1), around(idx, r2, y2))
+			default:
+				addQuad(around(idx, r1, y1), around(idx+1, r1, y1), around(idx+1, r2, y2), around(idx, r2, y2))
+			}
+			if closed && r1 > EPSILON {
+				addFacing(NewVector(0, -1, 0), NewPoint(0, y1, 0), around(idx, r1, y1), around(idx+1, r1, y1))
+			}
+			if closed && r2 > EPSILON {
+				addFacing(NewVector(0, 1, 0), NewPoint(0, y2, 0), around(idx, r2, y2), around(idx+1, r2, y2))
+			}
+		}
+	}
+
+	switch shape := si.(type) {
+	case *Triangle:
+		triangles = append(triangles, MeshTriangle{shape.P1, shape.P2, shape.P3})
+	case *SmoothTriangle:
+		triangles = append(triangles, MeshTriangle{shape.P1, shape.P2, shape.P3})
+	case *Mesh:
+		for _, tri := range shape.Triangles {
+			triangles = append(triangles, MeshTriangle{shape.Points[tri[0]], shape.Points[tri[1]], shape.Points[tri[2]]})
+		}
+	case *Sphere:
+		rings := maxInt(segments/2, 2)
+		at := func(idx, ring int) Tuple {
+			phi := math.Pi * float64(ring) / float64(rings)
+			p := around(idx, math.Sin(phi), math.Cos(phi))
+			if ring == 0 || ring == rings { // the poles
+				p = NewPoint(0, math.Cos(phi), 0)
+			}
+			return shape.Origin.Add(NewVector(p.X, p.Y, p.Z).Multiply(shape.Radius))
+		}
+		for ring := 0; ring < rings; ring++ {
+			for idx := 0; idx < segments; idx++ {
+				if ring == 0 {
+					add(at(idx, ring), at(idx, ring+1), at(idx+1, ring+1))
+				} else if ring == rings-1 {
+					add(at(idx, ring), at(idx+1, ring), at(idx, ring+1))
+				} else {
+					addQuad(at(idx, ring), at(idx+1, ring), at(idx+1, ring+1), at(idx, ring+1))
+				}
+			}
+		}
+	case *Cube:
+		for _, axis := range []int{0, 1, 2} {
+			for _, side := range []float64{-1, 1} {
+				corner := func(a, b float64) Tuple {
+					coords := [3]float64{}
+					coords[axis], coords[(axis+1)%3], coords[(axis+2)%3] = side, a, b
+					return NewPoint(coords[0], coords[1], coords[2])
+				}
+				addQuad(corner(-1, -1), corner(1, -1), corner(1, 1), corner(-1, 1))
+			}
+		}
+	case *Cylinder:
+		if !math.IsInf(shape.Minimum, 0) && !math.IsInf(shape.Maximum, 0) {
+			addSide(1, shape.Minimum, 1, shape.Maximum, shape.Closed)
+		}
+	case *Cone:
+		if !math.IsInf(shape.Minimum, 0) && !math.IsInf(shape.Maximum, 0) {
+			if shape.Minimum < 0 && shape.Maximum > 0 { // a double cone, which meets at its tip
+				addSide(-shape.Minimum, shape.Minimum, 0, 0, shape.Closed)
+				addSide(0, 0, shape.Maximum, shape.Maximum, shape.Closed)
+			} else {
+				addSide(math.Abs(shape.Minimum), shape.Minimum, math.Abs(shape.Maximum), shape.Maximum, shape.Closed)
+			}
+		}
+	}
+	return triangles
+}
+
+// Writes triangles to w as an OBJ file, with each distinct point written once.
+func WriteObj(w io.Writer, triangles []MeshTriangle) error {
+	bw := bufio.NewWriter(w)
+	vertexIndices := map[Tuple]int{}
+	faces := make([][3]int, len(triangles))
+
+	for idx, t := range triangles {
+		for corner, p := range []Tuple{t.P1, t.P2, t.P3} {
+			vi, found := vertexIndices[p]
+			if !found {
+				vi = len(vertexIndices) + 1 // OBJ indices start at 1
+				vertexIndices[p] = vi
+				fmt.Fprintf(bw, "v %g %g %g\n", p.X, p.Y, p.Z)
+			}
+			faces[idx][corner] = vi
+		}
+	}
+	for _, f := range faces {
+		fmt.Fprintf(bw, "f %d %d %d\n", f[0], f[1], f[2])
+	}
+	return bw.Flush()
+}
+
+// Writes triangles to w as an ASCII STL file, with the given name.
+func WriteAsciiStl(w io.Writer, name string, triangles []MeshTriangle) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "solid %s\n", name)
+	for _, t := range triangles {
+		n := t.Normal()
+		fmt.Fprintf(bw, "  facet normal %g %g %g\n    outer loop\n", n.X, n.Y, n.Z)
+		for _, p := range []Tuple{t.P1, t.P2, t.P3} {
+			fmt.Fprintf(bw, "      vertex %g %g %g\n", p.X, p.Y, p.Z)
+		}
+		fmt.Fprintf(bw, "    endloop\n  endfacet\n")
+	}
+	fmt.Fprintf(bw, "endsolid %s\n", name)
+	return bw.Flush()
+}
+
+// Writes triangles to w as a binary STL file: an 80 byte header, the number of triangles,
+// and then each triangle's normal and points as little-endian float32s.
+func WriteBinaryStl(w io.Writer, triangles []MeshTriangle) error {
+	bw := bufio.NewWriter(w)
+	header := [80]byte{}
+	copy(header[:], "raytracer-go")
+	if _, err := bw.Write(header[:]); err != nil {
+		return err
+	}
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(triangles))); err != nil {
+		return err
+	}
+
+	for _, t := range triangles {
+		facet := [12]float32{}
+		for idx, v := range []Tuple{t.Normal(), t.P1, t.P2, t.P3} {
+			facet[idx*3], facet[idx*3+1], facet[idx*3+2] = float32(v.X), float32(v.Y), float32(v.Z)
+		}
+		if err := binary.Write(bw, binary.LittleEndian, facet); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.LittleEndian, uint16(0)); err != nil { // "attribute byte count"
+			return err
+		}
+	}
+	return bw.Flush()
+}
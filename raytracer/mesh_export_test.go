@@ -0,0 +1,192 @@
+package raytracer
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTessellatingACube(t *testing.T) {
+	triangles := Tessellate(NewCube(), DefaultTessellationSegments)
+
+	assertEqualInt(t, 12, len(triangles))
+	for _, tri := range triangles {
+		// Every triangle faces away from the center of the cube.
+		if tri.Normal().Dot(tri.centroid().Subtract(NewPoint(0, 0, 0))) <= 0 {
+			t.Errorf("Expected %v to face outwards", tri)
+		}
+	}
+}
+
+func TestTessellatingATransformedSphere(t *testing.T) {
+	s := NewSphere()
+	transform := NewTranslation(1, 2, 3)
+	s.SetTransform(transform.Multiply(NewScale(2, 2, 2)))
+	g := NewGroup()
+	g.AddChildren(s)
+	g.SetTransform(NewTranslation(0, 10, 0))
+	triangles := Tessellate(g, 8)
+
+	assertEqualInt(t, 2*8+2*16, len(triangles)) // 4 rings, with triangles at the poles
+	center := NewPoint(1, 12, 3)
+	for _, tri := range triangles {
+		assertEqualFloat64(t, 2, tri.P1.Subtract(center).Magnitude())
+		if tri.Normal().Dot(tri.centroid().Subtract(center)) <= 0 {
+			t.Errorf("Expected %v to face outwards", tri)
+		}
+	}
+}
+
+func TestTessellatingAMirroredClosedCylinderKeepsItFacingOutwards(t *testing.T) {
+	s := NewCylinder()
+	cyl := s.LocalShape.(*Cylinder)
+	cyl.Minimum = -1
+	cyl.Maximum = 1
+	cyl.Closed = true
+	s.SetTransform(NewScale(-1, 1, 1))
+	triangles := Tessellate(s, 16)
+
+	assertEqualInt(t, 16*4, len(triangles))
+	for _, tri := range triangles {
+		if tri.Normal().Dot(tri.centroid().Subtract(NewPoint(0, 0, 0))) <= 0 {
+			t.Errorf("Expected %v to face outwards", tri)
+		}
+	}
+}
+
+func TestInfiniteShapesAreNotTessellated(t *testing.T) {
+	assertEqualInt(t, 0, len(Tessellate(NewPlane(), DefaultTessellationSegments)))
+	assertEqualInt(t, 0, len(Tessellate(NewCylinder(), DefaultTessellationSegments)))
+}
+
+func TestTessellatingACsgKeepsTrianglesOnItsSurface(t *testing.T) {
+	testCases := []struct {
+		operation    string
+		outerCount   int
+		innerCount   int
+		innerOutward bool
+	}{
+		// A small cube inside a big one.
+		{"union", 12, 0, true},
+		{"intersection", 0, 12, true},
+		{"difference", 12, 12, false}, // i.e. a hollow cube
+	}
+	for _, tc := range testCases {
+		t.Run(tc.operation, func(t *testing.T) {
+			outer := NewCube()
+			inner := NewCube()
+			inner.SetTransform(NewScale(0.5, 0.5, 0.5))
+			triangles := Tessellate(NewCsg(tc.operation, outer, inner), DefaultTessellationSegments)
+
+			outerCount, innerCount := 0, 0
+			for _, tri := range triangles {
+				outward := tri.Normal().Dot(tri.centroid().Subtract(NewPoint(0, 0, 0))) > 0
+				if tri.P1.X == 1 || tri.P1.X == -1 {
+					outerCount += 1
+				} else {
+					innerCount += 1
+					if outward != tc.innerOutward {
+						t.Errorf("Expected %v to face outwards: %v", tri, tc.innerOutward)
+					}
+				}
+			}
+			assertEqualInt(t, tc.outerCount, outerCount)
+			assertEqualInt(t, tc.innerCount, innerCount)
+		})
+	}
+}
+
+func TestTessellatingATransformedCsgKeepsTheSameTriangles(t *testing.T) {
+	union := func() *Shape {
+		s1 := NewSphere()
+		s2 := NewSphere()
+		s2.SetTransform(NewTranslation(1, 0, 0))
+		return NewCsg("union", s1, s2)
+	}
+	allCount := 2 * len(Tessellate(NewSphere(), 16))
+	expectedCount := len(Tessellate(union(), 16))
+	if expectedCount >= allCount {
+		t.Fatalf("Expected the union to remove triangles, but it kept all %d", allCount)
+	}
+
+	inGroup := union()
+	g := NewGroup()
+	g.AddChildren(inGroup)
+	g.SetTransform(NewTranslation(10, 0, 0))
+	transformed := union()
+	transform := NewRotateY(math.Pi / 3)
+	transformed.SetTransform(transform.Multiply(NewScale(2, 2, 2)))
+
+	testCases := []*Shape{g, transformed}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualInt(t, expectedCount, len(Tessellate(tc, 16)))
+		})
+	}
+}
+
+func TestWritingAnObjFileThatCanBeParsedAgain(t *testing.T) {
+	triangles := Tessellate(NewCube(), DefaultTessellationSegments)
+	var b bytes.Buffer
+	if err := WriteObj(&b, triangles); err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqualInt(t, 8, strings.Count(b.String(), "v "))
+	parser, err := ParseObj(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := parser.DefaultGroup.LocalShape.(Group).Children
+	assertEqualInt(t, 12, len(children))
+	for idx, child := range children {
+		assertEqualTuple(t, triangles[idx].Normal(), child.LocalShape.(*Triangle).Normal)
+	}
+}
+
+func TestWritingAnObjFileSharesTheVerticesOfRoundShapes(t *testing.T) {
+	cylinder := NewCylinder()
+	cyl := cylinder.LocalShape.(*Cylinder)
+	cyl.Minimum = -1
+	cyl.Maximum = 1
+	cyl.Closed = true
+
+	testCases := []struct {
+		shape    *Shape
+		vertices int
+	}{
+		{NewSphere(), 2 + 3*8}, // the poles, and 3 rings between them
+		{cylinder, 2*8 + 2},    // 2 rings, and the centers of the caps
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			var b bytes.Buffer
+			if err := WriteObj(&b, Tessellate(tc.shape, 8)); err != nil {
+				t.Fatal(err)
+			}
+			assertEqualInt(t, tc.vertices, strings.Count(b.String(), "v "))
+		})
+	}
+}
+
+func TestWritingStlFiles(t *testing.T) {
+	triangles := Tessellate(NewCube(), DefaultTessellationSegments)
+
+	var ascii bytes.Buffer
+	if err := WriteAsciiStl(&ascii, "cube", triangles); err != nil {
+		t.Fatal(err)
+	}
+	assertEqualInt(t, 12, strings.Count(ascii.String(), "facet normal"))
+	if !strings.HasPrefix(ascii.String(), "solid cube\n") || !strings.HasSuffix(ascii.String(), "endsolid cube\n") {
+		t.Errorf("Expected an ASCII STL solid named cube, but got:\n%s", ascii.String())
+	}
+
+	var binary bytes.Buffer
+	if err := WriteBinaryStl(&binary, triangles); err != nil {
+		t.Fatal(err)
+	}
+	assertEqualInt(t, 80+4+12*50, binary.Len())
+	assertEqualInt(t, 12, int(binary.Bytes()[80]))
+}
@@ -0,0 +1,161 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"testing"
+)
+
+// Returns a mesh of a square from (-1, 0, -1) to (1, 0, 1), as two triangles.
+func newTestSquareMesh() *Shape {
+	return NewMesh(
+		[]Tuple{NewPoint(-1, 0, -1), NewPoint(1, 0, -1), NewPoint(1, 0, 1), NewPoint(-1, 0, 1)},
+		[][3]int{{0, 1, 2}, {0, 2, 3}},
+	)
+}
+
+func TestIntersectingAMesh(t *testing.T) {
+	s := newTestSquareMesh()
+	s.SetTransform(NewTranslation(0, 1, 0))
+	testCases := []struct {
+		ray   *Ray
+		count int
+		face  int
+	}{
+		{NewRay(NewPoint(0.5, 5, -0.1), NewVector(0, -1, 0)), 1, 0},
+		{NewRay(NewPoint(-0.5, 5, 0.1), NewVector(0, -1, 0)), 1, 1},
+		{NewRay(NewPoint(0, 5, 2), NewVector(0, -1, 0)), 0, 0},
+		{NewRay(NewPoint(0, 5, 0), NewVector(1, 0, 0)), 0, 0},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			xs := s.Intersect(tc.ray)
+			assertEqualInt(t, tc.count, len(xs))
+			if tc.count > 0 {
+				assertEqualFloat64(t, 4, xs[0].Time)
+				assertEqualInt(t, tc.face, xs[0].Face)
+				assertEqualTuple(t, NewVector(0, 1, 0), s.NormalAt(tc.ray.Position(4), xs[0]))
+			}
+		})
+	}
+	assertEqualTuple(t, NewPoint(-1, 0, -1), s.Bounds().MinPoint)
+	assertEqualTuple(t, NewPoint(1, 0, 1), s.Bounds().MaxPoint)
+}
+
+func TestAMeshInterpolatesItsNormalsAndUVs(t *testing.T) {
+	s := newTestSquareMesh()
+	mesh := s.LocalShape.(*Mesh)
+	mesh.Normals = []Tuple{NewVector(-1, 0, 0), NewVector(1, 0, 0), NewVector(0, 1, 0)}
+	mesh.NormalIndices = [][3]int{{0, 1, 2}, {0, 2, 2}}
+	mesh.UVs = []Tuple{NewPoint(0, 0, 0), NewPoint(1, 0, 0), NewPoint(1, 1, 0), NewPoint(0, 1, 0)}
+	mesh.UVIndices = [][3]int{{0, 1, 2}, {0, 2, 3}}
+
+	r := NewRay(NewPoint(0.5, 1, -0.5), NewVector(0, -1, 0))
+	xs := s.Intersect(r)
+	assertEqualInt(t, 1, len(xs))
+	assertEqualFloat64(t, 0.5, xs[0].U)
+	assertEqualFloat64(t, 0.25, xs[0].V)
+	assertEqualTuple(t, NewVector(0.25, 0.25, 0), s.LocalShape.LocalNormalAt(NewPoint(0.5, 0, -0.5), xs[0]))
+
+	testCases := []struct {
+		point Tuple
+		u, v  float64
+	}{
+		{NewPoint(0.5, 0, -0.5), 0.75, 0.25},
+		{NewPoint(-0.5, 0, 0.5), 0.25, 0.75},
+		{NewPoint(-1, 0, -1), 0, 0},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			// Without an intersection, the triangle is found from the point.
+			u, v, ok := s.UVAt(tc.point, nil)
+			if !ok {
+				t.Fatalf("Expected a mesh to have UVs")
+			}
+			assertEqualFloat64(t, tc.u, u)
+			assertEqualFloat64(t, tc.v, v)
+		})
+	}
+}
+
+func TestAMeshFromAnObjFileIntersectsLikeItsTriangles(t *testing.T) {
+	load := func(meshes bool) *Shape {
+		f, err := os.Open("files/utah_teapot_lowres.obj")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		of, err := ParseObjWithOptions(f, ObjOptions{SmoothNormals: true, Meshes: meshes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return of.ToGroup()
+	}
+	triangles, mesh := load(false), load(true)
+	triangles.Divide(4)
+
+	meshCount := 0
+	for _, g := range mesh.LocalShape.(Group).Children {
+		for _, child := range g.LocalShape.(Group).Children {
+			if _, isMesh := child.LocalShape.(*Mesh); isMesh {
+				meshCount += 1
+			}
+		}
+	}
+	assertEqualInt(t, 1, meshCount)
+	for x := -10; x <= 10; x++ {
+		for z := -10; z <= 10; z++ {
+			r := NewRay(NewPoint(float64(x)*0.9, float64(z)*0.9, 30), NewVector(0.01*float64(x), 0.02, -1))
+			expected, actual := triangles.Intersect(r), mesh.Intersect(r)
+			assertEqualInt(t, len(expected), len(actual))
+			for idx := range expected {
+				if idx >= len(actual) {
+					break
+				}
+				assertEqualFloat64(t, expected[idx].Time, actual[idx].Time)
+			}
+			if len(expected) > 0 && len(actual) > 0 {
+				point := r.Position(expected[0].Time)
+				assertEqualTuple(t, expected[0].Object.NormalAt(point, expected[0]), actual[0].Object.NormalAt(point, actual[0]))
+			}
+		}
+	}
+}
+
+func TestAnEmptyMeshIsNotIntersected(t *testing.T) {
+	s := NewMesh([]Tuple{}, [][3]int{})
+	assertEqualInt(t, 0, len(s.Intersect(NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1)))))
+	if !math.IsInf(s.Bounds().MinPoint.X, 1) {
+		t.Errorf("Expected an empty mesh to have empty bounds, but got %v", s.Bounds())
+	}
+}
+
+/////////////
+// Benchmarks
+/////////////
+
+func BenchmarkMeshMethodIntersect(b *testing.B) {
+	// A 300x300 grid of squares, i.e. 180,000 triangles.
+	size := 300
+	points := []Tuple{}
+	triangles := [][3]int{}
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			points = append(points, NewPoint(float64(x), math.Sin(float64(x+z)), float64(z)))
+		}
+	}
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			i := z*(size+1) + x
+			triangles = append(triangles, [3]int{i, i + 1, i + size + 2}, [3]int{i, i + size + 2, i + size + 1})
+		}
+	}
+	s := NewMesh(points, triangles)
+	r := NewRay(NewPoint(0, 10, 0), NewVector(1, -0.1, 1))
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Intersect(r)
+	}
+}
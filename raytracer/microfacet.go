@@ -0,0 +1,85 @@
+package raytracer
+
+import "math"
+
+// The smallest roughness used for the GGX distribution, since a perfectly smooth
+// surface would only reflect point lights into a single (infinitely bright) direction.
+const minimumRoughness = 0.045
+
+// Calculates the lighting for a material with the PBRModel, based on the Cook-Torrance
+// microfacet model (like Lighting() is for the Phong model):
+//   - Diffuse:  Lambertian reflection of the base color, for light that isn't reflected by the surface.
+//   - Specular: GGX distribution of microfacets, Smith's geometry term (microfacets shadowing each
+//     other) and Schlick's approximation of Fresnel reflectance.
+//
+// Metals have no diffuse reflection and tint their specular reflection with the base color.
+// Dielectrics reflect based on RefractiveIndex, e.g. 1.5 reflects 4% of light head-on.
+// Ambient is the same as in the Phong model.
+func (m *Material) pbrLightingAt(c *Computation, light *AreaLight, intensity float64) Color {
+	point, eyeVector, normalVector := c.OverPoint, c.EyeV, c.NormalV
+
+	baseColor := m.colorAtComputation(c)
+	ambient := baseColor.MultiplyColor(light.GetIntensity()).MultiplyColor(m.Ambient).Multiply(c.Occlusion)
+
+	f0 := m.fresnelReflectanceAtZero(baseColor)
+	alpha := math.Pow(math.Max(m.Roughness, minimumRoughness), 2)
+	normalDotEye := math.Max(normalVector.Dot(eyeVector), EPSILON)
+
+	sum := Colors["Black"]
+
+	// Loop through each of the points in the area light
+	for _, sample := range light.SamplePoints(point) {
+		lightVector := sample.Subtract(point).Normalized()
+		normalDotLight := lightVector.Dot(normalVector)
+		if normalDotLight < EPSILON || intensity < EPSILON {
+			continue
+		}
+
+		halfway := lightVector.Add(eyeVector).Normalized()
+		normalDotHalfway := math.Max(normalVector.Dot(halfway), 0)
+		eyeDotHalfway := math.Max(eyeVector.Dot(halfway), 0)
+
+		fresnel := schlickColor(f0, eyeDotHalfway)
+		distribution := ggxDistribution(normalDotHalfway, alpha)
+		geometry := smithGeometry(normalDotEye, normalDotLight, m.Roughness)
+
+		specular := fresnel.Multiply(distribution * geometry / (4 * normalDotEye * normalDotLight))
+		// Whatever isn't reflected is refracted and diffused, except by metals which absorb it.
+		diffuse := Colors["White"].Subtract(fresnel).Multiply(1 - m.Metallic).MultiplyColor(baseColor).Divide(math.Pi)
+
+		// NB: scaled by π so that a white, rough dielectric is about as bright as the Phong model's default.
+		sum = sum.Add(diffuse.Add(specular).Multiply(normalDotLight * math.Pi))
+	}
+
+	return ambient.Add(sum.MultiplyColor(light.GetIntensity()).Divide(light.Samples).Multiply(intensity))
+}
+
+// Returns the reflectance of the material when looking straight at it.
+func (m *Material) fresnelReflectanceAtZero(baseColor Color) Color {
+	r0 := math.Pow((m.RefractiveIndex-1)/(m.RefractiveIndex+1), 2)
+	dielectric := NewColor(r0, r0, r0)
+	return dielectric.Multiply(1 - m.Metallic).Add(baseColor.Multiply(m.Metallic))
+}
+
+// Schlick's approximation of Fresnel reflectance (see Computation.Schlick), per color channel.
+func schlickColor(f0 Color, cos float64) Color {
+	factor := math.Pow(1-cos, 5)
+	return f0.Add(Colors["White"].Subtract(f0).Multiply(factor))
+}
+
+// The GGX (Trowbridge-Reitz) normal distribution: how many microfacets face the halfway vector.
+func ggxDistribution(normalDotHalfway, alpha float64) float64 {
+	alphaSquared := alpha * alpha
+	d := normalDotHalfway*normalDotHalfway*(alphaSquared-1) + 1
+	return alphaSquared / (math.Pi * d * d)
+}
+
+// Smith's geometry term with the Schlick-GGX approximation: how many microfacets are
+// visible from both the eye and the light.
+func smithGeometry(normalDotEye, normalDotLight, roughness float64) float64 {
+	k := math.Pow(roughness+1, 2) / 8
+	schlickGGX := func(cos float64) float64 {
+		return cos / (cos*(1-k) + k)
+	}
+	return schlickGGX(normalDotEye) * schlickGGX(normalDotLight)
+}
@@ -0,0 +1,70 @@
+package raytracer
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// Reads and parses an MTL file (see ParseMtlFile), with textures relative to it.
+func LoadMtlFile(path string) (map[string]*Material, error) {
+	dat, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMtlFile(string(dat), filepath.Dir(path))
+}
+
+// Parses the contents of an MTL file (the materials of an OBJ file) into Materials, by name.
+// Supported statements:
+//   - Kd:     diffuse color (Color)
+//   - Ks:     specular color (Specular)
+//   - Ns:     specular exponent (Shininess)
+//   - Ni:     index of refraction (RefractiveIndex)
+//   - d / Tr: dissolve, i.e. opacity / transparency (Transparency)
+//   - map_Kd: diffuse texture (Pattern), loaded relative to dir, using the triangles' UVs
+func ParseMtlFile(s string, dir string) (map[string]*Material, error) {
+	materials := map[string]*Material{}
+	var m *Material
+	var r, g, b, f float64
+	var name string
+
+	scanner := bufio.NewScanner(strings.NewReader(s))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if _, err := fmt.Sscanf(line, "newmtl %s", &name); err == nil {
+			m = DefaultMaterial()
+			m.Label = name
+			materials[name] = m
+			continue
+		} else if m == nil {
+			continue // e.g. comments before the first material
+		}
+
+		if n, err := fmt.Sscanf(line, "Kd %f %f %f", &r, &g, &b); err == nil && n == 3 {
+			m.Color = NewColor(r, g, b)
+		} else if n, err := fmt.Sscanf(line, "Ks %f %f %f", &r, &g, &b); err == nil && n == 3 {
+			m.Specular = NewColor(r, g, b)
+		} else if _, err := fmt.Sscanf(line, "Ns %f", &f); err == nil {
+			m.Shininess = f
+		} else if _, err := fmt.Sscanf(line, "Ni %f", &f); err == nil {
+			m.RefractiveIndex = f
+		} else if _, err := fmt.Sscanf(line, "d %f", &f); err == nil {
+			m.Transparency = 1 - f
+		} else if _, err := fmt.Sscanf(line, "Tr %f", &f); err == nil {
+			m.Transparency = f
+		} else if strings.HasPrefix(line, "map_Kd ") {
+			// NB options (e.g. "-s 2 2 2") aren't supported, so the file name is the last field.
+			fields := strings.Fields(line)
+			c, err := LoadCanvas(filepath.Join(dir, fields[len(fields)-1]))
+			if err != nil {
+				return nil, err
+			}
+			m.Pattern = NewShapeTextureMapPattern(NewFilteredUVImagePattern(c, FilterBilinear, WrapRepeat))
+		}
+	}
+	return materials, nil
+}
@@ -0,0 +1,40 @@
+package raytracer
+
+import "testing"
+
+func TestParsingAnMTLFile(t *testing.T) {
+	file := `# two materials
+newmtl shiny
+Kd 0.8 0.1 0.1
+Ks 0.5 0.5 0.5
+Ns 96
+illum 2
+
+newmtl glass
+Kd 1 1 1
+Ni 1.5
+d 0.25`
+	materials, err := ParseMtlFile(file, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqualInt(t, 2, len(materials))
+	shiny := materials["shiny"]
+	assertEqualColor(t, NewColor(0.8, 0.1, 0.1), shiny.Color)
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), shiny.Specular)
+	assertEqualFloat64(t, 96, shiny.Shininess)
+	glass := materials["glass"]
+	assertEqualFloat64(t, 1.5, glass.RefractiveIndex)
+	assertEqualFloat64(t, 0.75, glass.Transparency)
+}
+
+func TestParsingAnMTLFileWithAMissingTexture(t *testing.T) {
+	file := `newmtl textured
+map_Kd does-not-exist.png`
+	_, err := ParseMtlFile(file, t.TempDir())
+
+	if err == nil {
+		t.Errorf("Expected an error for a missing texture")
+	}
+}
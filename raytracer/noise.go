@@ -0,0 +1,130 @@
+package raytracer
+
+import "math"
+
+// Ken Perlin's "improved noise" (https://mrl.cs.nyu.edu/~perlin/noise/), i.e. smooth
+// pseudo-random values that vary continuously through 3D space. Useful for making
+// natural-looking surfaces and patterns.
+
+// Returns the noise at the given point, from -1 to 1 (but usually a lot closer to 0).
+// It's 0 at every integer coordinate, and repeats every 256 units.
+func PerlinNoise(x, y, z float64) float64 {
+	floorX, floorY, floorZ := math.Floor(x), math.Floor(y), math.Floor(z)
+
+	// Find the unit cube that contains the point
+	X, Y, Z := int(floorX)&255, int(floorY)&255, int(floorZ)&255
+
+	// Find the relative x,y,z of the point in the cube
+	x, y, z = x-floorX, y-floorY, z-floorZ
+
+	// Compute fade curves for each of x,y,z
+	u, v, w := perlinFade(x), perlinFade(y), perlinFade(z)
+
+	// Hash coordinates of the 8 cube corners
+	p := perlinPermutation
+	A := p[X] + Y
+	AA := p[A] + Z
+	AB := p[A+1] + Z
+	B := p[X+1] + Y
+	BA := p[B] + Z
+	BB := p[B+1] + Z
+
+	// And add blended results from 8 corners of the cube
+	return perlinLerp(w,
+		perlinLerp(v,
+			perlinLerp(u, perlinGrad(p[AA], x, y, z), perlinGrad(p[BA], x-1, y, z)),
+			perlinLerp(u, perlinGrad(p[AB], x, y-1, z), perlinGrad(p[BB], x-1, y-1, z))),
+		perlinLerp(v,
+			perlinLerp(u, perlinGrad(p[AA+1], x, y, z-1), perlinGrad(p[BA+1], x-1, y, z-1)),
+			perlinLerp(u, perlinGrad(p[AB+1], x, y-1, z-1), perlinGrad(p[BB+1], x-1, y-1, z-1))))
+}
+
+func perlinFade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+func perlinLerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
+
+// Converts the low 4 bits of the hash into 12 gradient directions.
+func perlinGrad(hash int, x, y, z float64) float64 {
+	h := hash & 15
+	u := y
+	if h < 8 {
+		u = x
+	}
+	var v float64
+	if h < 4 {
+		v = y
+	} else if h == 12 || h == 14 {
+		v = x
+	} else {
+		v = z
+	}
+	if h&1 != 0 {
+		u = -u
+	}
+	if h&2 != 0 {
+		v = -v
+	}
+	return u + v
+}
+
+// Ken Perlin's permutation of 0..255, repeated twice to avoid wrapping indexes.
+var perlinPermutation = func() [512]int {
+	permutation := [256]int{151, 160, 137, 91, 90, 15,
+		131, 13, 201, 95, 96, 53, 194, 233, 7, 225, 140, 36, 103, 30, 69, 142, 8, 99, 37, 240, 21, 10, 23,
+		190, 6, 148, 247, 120, 234, 75, 0, 26, 197, 62, 94, 252, 219, 203, 117, 35, 11, 32, 57, 177, 33,
+		88, 237, 149, 56, 87, 174, 20, 125, 136, 171, 168, 68, 175, 74, 165, 71, 134, 139, 48, 27, 166,
+		77, 146, 158, 231, 83, 111, 229, 122, 60, 211, 133, 230, 220, 105, 92, 41, 55, 46, 245, 40, 244,
+		102, 143, 54, 65, 25, 63, 161, 1, 216, 80, 73, 209, 76, 132, 187, 208, 89, 18, 169, 200, 196,
+		135, 130, 116, 188, 159, 86, 164, 100, 109, 198, 173, 186, 3, 64, 52, 217, 226, 250, 124, 123,
+		5, 202, 38, 147, 118, 126, 255, 82, 85, 212, 207, 206, 59, 227, 47, 16, 58, 17, 182, 189, 28, 42,
+		223, 183, 170, 213, 119, 248, 152, 2, 44, 154, 163, 70, 221, 153, 101, 155, 167, 43, 172, 9,
+		129, 22, 39, 253, 19, 98, 108, 110, 79, 113, 224, 232, 178, 185, 112, 104, 218, 246, 97, 228,
+		251, 34, 242, 193, 238, 210, 144, 12, 191, 179, 162, 241, 81, 51, 145, 235, 249, 14, 239, 107,
+		49, 192, 214, 31, 181, 199, 106, 157, 184, 84, 204, 176, 115, 121, 50, 45, 127, 4, 150, 254,
+		138, 236, 205, 93, 222, 114, 67, 29, 24, 72, 243, 141, 128, 195, 78, 66, 215, 61, 156, 180,
+	}
+	p := [512]int{}
+	for i := 0; i < 256; i++ {
+		p[i] = permutation[i]
+		p[256+i] = permutation[i]
+	}
+	return p
+}()
+
+// Returns the sum of octaves layers of PerlinNoise, where each layer has twice the
+// frequency of the last one, and persistence times its amplitude (e.g. 0.5). This
+// adds finer and finer detail. It's normalized to stay within -1 to 1.
+func OctaveNoise(x, y, z float64, octaves int, persistence float64) float64 {
+	total, frequency, amplitude, maxValue := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		total += PerlinNoise(x*frequency, y*frequency, z*frequency) * amplitude
+		maxValue += amplitude
+		amplitude *= persistence
+		frequency *= 2
+	}
+	if maxValue == 0 {
+		return 0
+	}
+	return total / maxValue
+}
+
+// Returns Ken Perlin's turbulence: like OctaveNoise (with a persistence of 0.5), but
+// summing the absolute value of each layer, which makes sharp creases where the noise
+// crosses 0. It's normalized to stay within 0 to 1.
+func Turbulence(x, y, z float64, octaves int) float64 {
+	total, frequency, amplitude, maxValue := 0.0, 1.0, 1.0, 0.0
+	for i := 0; i < octaves; i++ {
+		total += math.Abs(PerlinNoise(x*frequency, y*frequency, z*frequency)) * amplitude
+		maxValue += amplitude
+		amplitude *= 0.5
+		frequency *= 2
+	}
+	if maxValue == 0 {
+		return 0
+	}
+	return total / maxValue
+}
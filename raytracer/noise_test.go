@@ -0,0 +1,48 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestPerlinNoiseIsZeroAtIntegerCoordinates(t *testing.T) {
+	testCases := []Tuple{
+		NewPoint(0, 0, 0),
+		NewPoint(1, 2, 3),
+		NewPoint(-4, 5, -6),
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualFloat64(t, 0, PerlinNoise(tc.X, tc.Y, tc.Z))
+		})
+	}
+}
+
+func TestPerlinNoiseIsSmoothAndBounded(t *testing.T) {
+	varies := false
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.137, float64(i)*0.071, float64(i)*-0.053
+		n := PerlinNoise(x, y, z)
+		assert(t, n >= -1 && n <= 1)
+		assert(t, math.Abs(PerlinNoise(x+0.001, y, z)-n) < 0.01)
+		if math.Abs(n) > 0.1 {
+			varies = true
+		}
+	}
+	assert(t, varies)
+}
+
+func TestOctaveNoiseWithOneOctaveIsPerlinNoise(t *testing.T) {
+	assertEqualFloat64(t, PerlinNoise(0.3, 1.7, 2.2), OctaveNoise(0.3, 1.7, 2.2, 1, 0.5))
+}
+
+func TestOctaveNoiseAndTurbulenceAreBounded(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		x, y, z := float64(i)*0.137, float64(i)*0.071, float64(i)*-0.053
+		n := OctaveNoise(x, y, z, 4, 0.5)
+		assert(t, n >= -1 && n <= 1)
+		turbulence := Turbulence(x, y, z, 4)
+		assert(t, turbulence >= 0 && turbulence <= 1)
+	}
+}
@@ -0,0 +1,150 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// NormalPerturbation changes the normal of a surface at each point, so it looks bumpy
+// or detailed (when shaded) without changing its geometry. Set with Material.NormalPerturbation.
+type NormalPerturbation interface {
+	// Returns the perturbed (normalized) normal, given a point on s and its unperturbed normal, all in world space.
+	PerturbNormal(s *Shape, worldPoint, worldNormal Tuple) Tuple
+}
+
+// How far apart the points are that slopes are estimated from.
+const perturbationDelta = 0.0001
+
+/////////////////////////
+// NoiseBump
+/////////////////////////
+
+// NoiseBump makes a surface look bumpy with PerlinNoise, e.g. for water or stucco.
+type NoiseBump struct {
+	Scale  float64 // how many bumps there are per unit (in object space)
+	Amount float64 // how bumpy they are
+}
+
+func NewNoiseBump(scale, amount float64) *NoiseBump {
+	return &NoiseBump{Scale: scale, Amount: amount}
+}
+
+func (nb *NoiseBump) String() string {
+	return fmt.Sprintf("NoiseBump( Scale: %v Amount: %v )", nb.Scale, nb.Amount)
+}
+
+func (nb *NoiseBump) PerturbNormal(s *Shape, worldPoint, worldNormal Tuple) Tuple {
+	height := func(p Tuple) float64 {
+		objectPoint := s.WorldToObject(p).Multiply(nb.Scale)
+		return PerlinNoise(objectPoint.X, objectPoint.Y, objectPoint.Z)
+	}
+	return bumpNormal(height, worldPoint, worldNormal, nb.Amount)
+}
+
+/////////////////////////
+// PatternBump
+/////////////////////////
+
+// PatternBump uses a pattern (e.g. a checker, or an image with a TextureMapPattern) as a
+// height map, where lighter colors are higher. The pattern is looked up like Material.Pattern.
+type PatternBump struct {
+	Pattern *Pattern
+	Amount  float64 // how high white is compared to black
+}
+
+func NewPatternBump(p *Pattern, amount float64) *PatternBump {
+	return &PatternBump{Pattern: p, Amount: amount}
+}
+
+func (pb *PatternBump) String() string {
+	return fmt.Sprintf("PatternBump( Pattern: %v Amount: %v )", pb.Pattern, pb.Amount)
+}
+
+func (pb *PatternBump) PerturbNormal(s *Shape, worldPoint, worldNormal Tuple) Tuple {
+	height := func(p Tuple) float64 {
+		return pb.Pattern.PatternAtShape(s, p).Luminance()
+	}
+	return bumpNormal(height, worldPoint, worldNormal, pb.Amount)
+}
+
+// Tilts the normal away from the slope of height at the point (i.e. bump mapping),
+// where the slope is estimated from nearby points on the surface.
+func bumpNormal(height func(Tuple) float64, worldPoint, worldNormal Tuple, amount float64) Tuple {
+	tangent, bitangent := worldNormal.OrthonormalBasis()
+
+	h := height(worldPoint)
+	slopeT := (height(worldPoint.Add(tangent.Multiply(perturbationDelta))) - h) / perturbationDelta
+	slopeB := (height(worldPoint.Add(bitangent.Multiply(perturbationDelta))) - h) / perturbationDelta
+
+	return worldNormal.
+		Subtract(tangent.Multiply(amount * slopeT)).
+		Subtract(bitangent.Multiply(amount * slopeB)).
+		Normalized()
+}
+
+/////////////////////////
+// NormalMap
+/////////////////////////
+
+// NormalMap perturbs normals with a tangent-space normal map, i.e. an image where each pixel's
+// red, green and blue are the x (along u), y (along v) and z (along the normal) of the normal,
+// scaled from -1..1 to 0..1. So the typical light blue (0.5, 0.5, 1) leaves the normal as is.
+// Points are mapped to the image with UVMap (e.g. SphericalMap or PlanarMap) in object space,
+// or with the shape's own UVs if it's nil (see UVShapeInterface).
+type NormalMap struct {
+	Pattern *Pattern // e.g. a UVImagePattern
+	UVMap   func(Tuple) (float64, float64)
+}
+
+func NewNormalMap(p *Pattern, uvMap func(Tuple) (float64, float64)) *NormalMap {
+	return &NormalMap{Pattern: p, UVMap: uvMap}
+}
+
+func (nm *NormalMap) String() string {
+	return fmt.Sprintf("NormalMap( Pattern: %v UVMap: %T )", nm.Pattern, nm.UVMap)
+}
+
+func (nm *NormalMap) PerturbNormal(s *Shape, worldPoint, worldNormal Tuple) Tuple {
+	uv := func(p Tuple) (float64, float64) {
+		if nm.UVMap == nil {
+			u, v, _ := s.UVAt(p, nil)
+			return u, v
+		}
+		return nm.UVMap(s.WorldToObject(p))
+	}
+
+	// Find which directions along the surface u and v increase in (i.e. the tangent
+	// and bitangent), from how they change between nearby points.
+	a, b := worldNormal.OrthonormalBasis()
+	u, v := uv(worldPoint)
+	ua, va := uv(worldPoint.Add(a.Multiply(perturbationDelta)))
+	ub, vb := uv(worldPoint.Add(b.Multiply(perturbationDelta)))
+	dua, dva := wrapUVDelta(ua-u), wrapUVDelta(va-v)
+	dub, dvb := wrapUVDelta(ub-u), wrapUVDelta(vb-v)
+
+	determinant := dua*dvb - dub*dva
+	if math.Abs(determinant) < EPSILON*EPSILON {
+		return worldNormal // the mapping doesn't vary here (e.g. at a pole)
+	}
+	tangent := a.Multiply(dvb).Subtract(b.Multiply(dva)).Divide(determinant).Normalized()
+	bitangent := b.Multiply(dua).Subtract(a.Multiply(dub)).Divide(determinant).Normalized()
+
+	color := nm.Pattern.UVPatternAt(u, v)
+	x, y, z := 2*color.Red-1, 2*color.Green-1, 2*color.Blue-1
+
+	return tangent.Multiply(x).
+		Add(bitangent.Multiply(y)).
+		Add(worldNormal.Multiply(z)).
+		Normalized()
+}
+
+// Returns the change in a u or v coordinate, accounting for it wrapping around from 1 to 0
+// (e.g. at the seam of SphericalMap).
+func wrapUVDelta(d float64) float64 {
+	if d > 0.5 {
+		return d - 1
+	} else if d < -0.5 {
+		return d + 1
+	}
+	return d
+}
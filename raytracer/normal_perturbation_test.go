@@ -0,0 +1,68 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestPatternBumpOfAFlatPatternDoesntChangeTheNormal(t *testing.T) {
+	s := NewPlane()
+	pb := NewPatternBump(NewSolidPattern(Colors["White"]), 1)
+
+	actual := pb.PerturbNormal(s, NewPoint(1, 0, 2), NewVector(0, 1, 0))
+	assertEqualTuple(t, NewVector(0, 1, 0), actual)
+}
+
+func TestPatternBumpTiltsTheNormalAwayFromTheSlope(t *testing.T) {
+	s := NewPlane()
+	// Gets higher (lighter) from x=0 to x=1, with a slope of 1.
+	pb := NewPatternBump(NewGradientPattern(Colors["Black"], Colors["White"]), 1)
+
+	actual := pb.PerturbNormal(s, NewPoint(0.5, 0, 0), NewVector(0, 1, 0))
+	assertEqualTuple(t, NewVector(-math.Sqrt(2)/2, math.Sqrt(2)/2, 0), actual)
+}
+
+func TestNoiseBumpPerturbsTheNormal(t *testing.T) {
+	s := NewPlane()
+	nb := NewNoiseBump(4, 0.5)
+
+	actual := nb.PerturbNormal(s, NewPoint(0.3, 0, 0.7), NewVector(0, 1, 0))
+	assertEqualFloat64(t, 1, actual.Magnitude())
+	assertNotEqualTuple(t, NewVector(0, 1, 0), actual)
+	assert(t, actual.Y > 0)
+
+	nb.Amount = 0
+	assertEqualTuple(t, NewVector(0, 1, 0), nb.PerturbNormal(s, NewPoint(0.3, 0, 0.7), NewVector(0, 1, 0)))
+}
+
+func TestNormalMapOnAPlane(t *testing.T) {
+	s := NewPlane()
+	testCases := []struct {
+		color  Color
+		result Tuple
+	}{
+		{NewColor(0.5, 0.5, 1), NewVector(0, 1, 0)}, // unchanged
+		{NewColor(1, 0.5, 0.5), NewVector(1, 0, 0)}, // along u, which is x for PlanarMap
+		{NewColor(0.5, 1, 0.5), NewVector(0, 0, 1)}, // along v, which is z for PlanarMap
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			nm := NewNormalMap(NewSolidPattern(tc.color), PlanarMap)
+			actual := nm.PerturbNormal(s, NewPoint(0.25, 0, 0.25), NewVector(0, 1, 0))
+			assertEqualTuple(t, tc.result, actual)
+		})
+	}
+}
+
+func TestPrepareComputationsPerturbsTheNormal(t *testing.T) {
+	s := NewPlane()
+	s.Material.NormalPerturbation = NewNormalMap(NewSolidPattern(NewColor(1, 0.5, 0.5)), PlanarMap)
+	r := NewRay(NewPoint(0.25, 1, 0.25), NewVector(0, -1, 0))
+	c := NewIntersection(1, s).PrepareComputations(r)
+
+	assertEqualTuple(t, NewVector(1, 0, 0), c.NormalV)
+	assertEqualTuple(t, NewVector(0, -1, 0), c.ReflectV) // grazes the perturbed surface
+	// The over point still uses the geometric normal.
+	assertEqualTuple(t, NewPoint(0.25, EPSILON, 0.25), c.OverPoint)
+}
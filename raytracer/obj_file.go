@@ -3,23 +3,84 @@ package raytracer
 import (
 	"bufio"
 	"fmt"
-	"regexp"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+// The ways that generated vertex normals can weight the faces around each vertex.
+const (
+	AreaWeighting  = "area"  // bigger faces count more (the default)
+	AngleWeighting = "angle" // faces count by their angle at the vertex, so it doesn't matter how they're triangulated
+)
+
+// Options for turning OBJ files into shapes (see ParseObjWithOptions).
+type ObjOptions struct {
+	SmoothNormals bool       // generate normals for all faces without them, as if they were in a smoothing group
+	Weighting     string     // how generated normals are averaged: AreaWeighting (or empty) or AngleWeighting
+	CreaseAngle   float64    // in radians: faces at a sharper angle to each other aren't smoothed together (0 for no limit)
+	Meshes        bool       // put the faces of each group (with each material) in a Mesh, instead of a Triangle each, to save memory
+	BVH           BVHOptions // how the BVHs of Meshes are built (see NewMeshWithOptions)
+}
+
 type ObjFile struct {
-	IgnoredLineCount int
-	Vertices         []Tuple
-	Normals          []Tuple
-	DefaultGroup     *Shape
-	Groups           map[string]*Shape
-	CurrentGroupName string
+	IgnoredLineCount    int
+	Vertices            []Tuple
+	Normals             []Tuple
+	TextureVertices     []Tuple // X=u, Y=v
+	VertexColors        []Color // the color of each of the Vertices, if the file has them (e.g. PLY files), otherwise nil
+	DefaultGroup        *Shape
+	Groups              map[string]*Shape
+	CurrentGroupName    string
+	Objects             map[string]*Shape // groups of the Groups named while each "o" object was current
+	CurrentObjectName   string
+	MaterialLibraries   []string             // the .mtl files named by "mtllib" lines
+	Materials           map[string]*Material // the materials in MaterialLibraries, by name (only loaded by LoadObjFile)
+	CurrentMaterialName string
+	SmoothingGroup      string // the current "s" smoothing group, or empty if it's off
+
+	options      ObjOptions
+	strict       bool            // are lines that can't be parsed errors (see ParseObj), or ignored?
+	objectGroups map[string]bool // the Groups that are in Objects (instead of directly in ToGroup())
+	smoothFaces  []objFace       // faces without normals in smoothing groups, which are triangulated at the end
+	meshes       []*objMesh      // with ObjOptions.Meshes, the faces of each group and material, which become Meshes at the end
+}
+
+// A face whose triangles are created once all the faces sharing its vertices are known.
+type objFace struct {
+	vertexIndices  []int
+	uvs            []Tuple
+	group          *Shape
+	material       *Material
+	smoothingGroup string
+}
+
+// The faces of a group with a material, for a Mesh (see ObjOptions.Meshes).
+type objMesh struct {
+	group         *Shape
+	material      *Material
+	points        []Tuple
+	pointIndices  map[int]int // the indices in points of the ObjFile's Vertices
+	triangles     [][3]int
+	normals       []Tuple
+	normalIndex   map[Tuple]int // the indices in normals of each normal, so they're only added once
+	normalIndices [][3]int      // -1 for triangles without normals
+	uvs           []Tuple
+	uvIndex       map[Tuple]int
+	uvIndices     [][3]int // -1 for triangles without UVs
 }
 
 func (of *ObjFile) ToGroup() *Shape {
 	g := NewGroup()
-	for _, v := range of.Groups {
+	for name, v := range of.Groups {
+		if !of.objectGroups[name] {
+			g.AddChildren(v)
+		}
+	}
+	for _, v := range of.Objects {
 		g.AddChildren(v)
 	}
 	return g
@@ -27,93 +88,535 @@ func (of *ObjFile) ToGroup() *Shape {
 
 func (o ObjFile) String() string {
 	return fmt.Sprintf(
-		"ObjFile(\n  IgnoredLineCount: %d\n  Vertices: %d\n  DefaultGroup: %d\n  Groups: %d\n  CurrentGroupName: %s\n)",
+		"ObjFile(\n  IgnoredLineCount: %d\n  Vertices: %d\n  DefaultGroup: %d\n  Groups: %d\n  CurrentGroupName: %s\n  Objects: %d\n)",
 		o.IgnoredLineCount,
 		len(o.Vertices),
 		len(o.DefaultGroup.LocalShape.(Group).Children),
 		len(o.Groups),
 		o.CurrentGroupName,
+		len(o.Objects),
 	)
 }
 
+// Parses the contents of an OBJ file. Faces use the material named by the last "usemtl"
+// line, if it's in Materials (which it never is here, since MaterialLibraries aren't
+// loaded: use LoadObjFile for that), otherwise they're red.
+// Lines that can't be parsed are ignored (see ParseObj for a stricter parser).
 func ParseObjFile(s string) ObjFile {
-	of := ObjFile{}
-	of.Vertices = []Tuple{NewPoint(0, 0, 0)} // first element is dummy, this is a 1-indexed array
-	of.Normals = []Tuple{NewVector(0, 0, 0)} // first element is dummy, this is a 1-indexed array
+	of, _ := parseObj(strings.NewReader(s), nil, false, ObjOptions{})
+	return *of
+}
+
+// Parses an OBJ file line by line from r, the same way as ParseObjFile, except that it
+// returns an error (with the line number) for lines that can't be parsed, e.g. a face
+// with an index that doesn't exist. Besides faces, vertices and groups it supports:
+//   - Negative (relative) indices, e.g. -1 for the last vertex so far.
+//   - "o" objects, whose groups are put together in Objects (and ToGroup()).
+//   - "s" smoothing groups: faces in one without normals get SmoothTriangles, with
+//     normals averaged from all the faces in the group that share each vertex.
+func ParseObj(r io.Reader) (*ObjFile, error) {
+	return parseObj(r, nil, true, ObjOptions{})
+}
+
+// Same as ParseObj, but with options, e.g. to generate normals for meshes without any.
+func ParseObjWithOptions(r io.Reader, options ObjOptions) (*ObjFile, error) {
+	return parseObj(r, nil, true, options)
+}
+
+// Reads and parses an OBJ file (see ParseObj), along with the MTL files it names (relative
+// to it), so that faces get the materials (and textures) they're meant to have.
+func LoadObjFile(path string) (*ObjFile, error) {
+	return LoadObjFileWithOptions(path, ObjOptions{})
+}
+
+// Same as LoadObjFile, but with options (see ParseObjWithOptions).
+func LoadObjFileWithOptions(path string, options ObjOptions) (*ObjFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	dir := filepath.Dir(path)
+	return parseObj(f, func(name string) (map[string]*Material, error) {
+		return LoadMtlFile(filepath.Join(dir, name))
+	}, true, options)
+}
+
+// Reads and parses a mesh file, with the parser for its extension: .obj (see
+// LoadObjFileWithOptions), .ply (see ParsePly) or .stl (see ParseStl).
+func LoadMesh(path string, options ObjOptions) (*ObjFile, error) {
+	var parse func(io.Reader, ObjOptions) (*ObjFile, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".obj":
+		return LoadObjFileWithOptions(path, options)
+	case ".ply":
+		parse = ParsePly
+	case ".stl":
+		parse = ParseStl
+	default:
+		return nil, fmt.Errorf("Unknown mesh file type: %s\n", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f, options)
+}
+
+// Parses an OBJ file, loading MTL files with loadMtl (if it's not nil). If strict,
+// it stops at the first line that can't be parsed, otherwise it ignores them.
+func parseObj(r io.Reader, loadMtl func(string) (map[string]*Material, error), strict bool, options ObjOptions) (*ObjFile, error) {
+	of := newObjFile(options)
+	of.strict = strict
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // some exporters write very long face lines
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber += 1
+		if err := of.parseLine(scanner.Text(), loadMtl); err != nil {
+			if strict {
+				return of, fmt.Errorf("OBJ line %d: %w\n", lineNumber, err)
+			}
+			of.IgnoredLineCount += 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return of, err
+	}
+
+	of.finish()
+
+	// fmt.Printf("The current group %s has %d children\n", of.CurrentGroupName, len(of.Groups[of.CurrentGroupName].LocalShape.(Group).Children))
+	return of, nil
+}
+
+// Returns an empty ObjFile, for parsing OBJ files (or other meshes, e.g. ParsePly) into.
+func newObjFile(options ObjOptions) *ObjFile {
+	of := &ObjFile{options: options}
+	of.Vertices = []Tuple{NewPoint(0, 0, 0)}        // first element is dummy, this is a 1-indexed array
+	of.Normals = []Tuple{NewVector(0, 0, 0)}        // first element is dummy, this is a 1-indexed array
+	of.TextureVertices = []Tuple{NewPoint(0, 0, 0)} // first element is dummy, this is a 1-indexed array
+	of.Materials = map[string]*Material{}
+	of.Objects = map[string]*Shape{}
+	of.objectGroups = map[string]bool{}
 	defaultGroup := NewGroup()
 	of.Groups = map[string]*Shape{
 		"": defaultGroup,
 	}
 	of.CurrentGroupName = ""
 	of.DefaultGroup = of.Groups[""]
+	return of
+}
 
-	scanner := bufio.NewScanner(strings.NewReader(s))
-	var fExtendedRegex = regexp.MustCompile(`(\d+)?/(\d+)?/(\d+)?`)
-	var va, vb, vc float64
-	var vna, vnb, vnc float64
-	var g string
-	for scanner.Scan() {
-		line := scanner.Text()
+// Parses one line of an OBJ file, e.g. "v 1 2 3".
+func (of *ObjFile) parseLine(line string, loadMtl func(string) (map[string]*Material, error)) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		of.IgnoredLineCount += 1
+		return nil
+	}
 
-		// Scan for vertices
-		if n, err := fmt.Sscanf(line, "v %f %f %f", &va, &vb, &vc); err == nil && n == 3 {
-			of.Vertices = append(of.Vertices, NewPoint(va, vb, vc))
-			continue
+	switch fields[0] {
+	case "v": // vertices (an optional fourth "w" coordinate, or colors, are ignored)
+		xyz, err := parseObjFloats(fields[1:], 3, 3)
+		if err != nil {
+			return err
+		}
+		of.Vertices = append(of.Vertices, NewPoint(xyz[0], xyz[1], xyz[2]))
+	case "vn": // vertex normals
+		xyz, err := parseObjFloats(fields[1:], 3, 3)
+		if err != nil {
+			return err
+		}
+		of.Normals = append(of.Normals, NewVector(xyz[0], xyz[1], xyz[2]))
+	case "vt": // texture vertices (an optional third "w" coordinate is ignored)
+		uv, err := parseObjFloats(fields[1:], 1, 2)
+		if err != nil {
+			return err
+		}
+		of.TextureVertices = append(of.TextureVertices, NewPoint(uv[0], uv[1], 0))
+	case "f": // faces
+		return of.parseFace(fields[1:])
+	case "g": // groups
+		of.CurrentGroupName = ""
+		if len(fields) > 1 {
+			of.CurrentGroupName = fields[1]
+		}
+		of.currentGroup()
+	case "o": // objects (their faces go in a group of the same name, until there's a "g")
+		if len(fields) < 2 {
+			return fmt.Errorf("Object needs a name")
 		}
+		of.CurrentObjectName = fields[1]
+		of.CurrentGroupName = fields[1]
+		if _, found := of.Objects[of.CurrentObjectName]; !found {
+			of.Objects[of.CurrentObjectName] = NewGroup()
+		}
+	case "s": // smoothing groups
+		if len(fields) < 2 {
+			return fmt.Errorf("Smoothing group needs a name or 'off'")
+		}
+		of.SmoothingGroup = fields[1]
+		if of.SmoothingGroup == "off" || of.SmoothingGroup == "0" {
+			of.SmoothingGroup = ""
+		}
+	case "usemtl": // materials
+		if len(fields) < 2 {
+			return fmt.Errorf("Material needs a name")
+		}
+		of.CurrentMaterialName = fields[1]
+	case "mtllib": // material libraries
+		for _, name := range fields[1:] {
+			of.MaterialLibraries = append(of.MaterialLibraries, name)
+			if loadMtl == nil {
+				continue
+			}
+			materials, err := loadMtl(name)
+			if err != nil {
+				return err
+			}
+			for materialName, m := range materials {
+				of.Materials[materialName] = m
+			}
+		}
+	default:
+		of.IgnoredLineCount += 1
+	}
+	return nil
+}
 
-		// Scan for vertex normals
-		if n, err := fmt.Sscanf(line, "vn %f %f %f", &vna, &vnb, &vnc); err == nil && n == 3 {
-			of.Normals = append(of.Normals, NewVector(vna, vnb, vnc))
-			continue
+// Parses between min and max floats (any more are ignored), e.g. the coordinates of a vertex.
+func parseObjFloats(fields []string, min, max int) ([]float64, error) {
+	if len(fields) < min {
+		return nil, fmt.Errorf("Expected %d numbers, but got %d", min, len(fields))
+	}
+	floats := make([]float64, max)
+	for idx := 0; idx < max && idx < len(fields); idx++ {
+		f, err := strconv.ParseFloat(fields[idx], 64)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot convert %s to float64", fields[idx])
 		}
+		floats[idx] = f
+	}
+	return floats, nil
+}
 
-		// Scan for faces
-		if strings.HasPrefix(line, "f ") {
-			faceVertices := []Tuple{}
-			faceNormals := []Tuple{}
+// Resolves a 1-based index into a list of count elements, which may be negative (i.e.
+// relative to the end of the list, so -1 is the last element).
+func resolveObjIndex(s string, count int) (int, error) {
+	idx, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("Cannot convert %s to int", s)
+	}
+	if idx < 0 {
+		idx = count + 1 + idx
+	}
+	if idx < 1 || idx > count {
+		return 0, fmt.Errorf("Index %s is out of range (there are %d)", s, count)
+	}
+	return idx, nil
+}
 
-			tokens := strings.Split(line, " ")
-			for _, token := range tokens {
-				if foo := fExtendedRegex.FindSubmatch([]byte(token)); len(foo) > 0 {
-					vertexIdx, _, normalIdx := foo[1], foo[2], foo[3] // TODO: 2 is the texture index
+// Parses the vertices of a face, e.g. "1/2/3" (vertex/texture vertex/normal), "1//3", "1/2" or "1".
+func (of *ObjFile) parseFace(tokens []string) error {
+	if len(tokens) < 3 {
+		return fmt.Errorf("Face needs at least 3 vertices, but has %d", len(tokens))
+	}
 
-					if vi, err := strconv.Atoi(string(vertexIdx)); err == nil {
-						faceVertices = append(faceVertices, of.Vertices[vi])
-					}
-					if ni, err := strconv.Atoi(string(normalIdx)); err == nil {
-						faceNormals = append(faceNormals, of.Normals[ni])
-					}
-				} else {
-					i, err := strconv.Atoi(token)
-					if err == nil {
-						faceVertices = append(faceVertices, of.Vertices[i])
+	vertexIndices := make([]int, 0, len(tokens))
+	faceNormals := make([]Tuple, 0, len(tokens))
+	faceUVs := make([]Tuple, 0, len(tokens))
+
+	for _, token := range tokens {
+		parts := strings.Split(token, "/")
+		if len(parts) > 3 {
+			return fmt.Errorf("Invalid face vertex: %s", token)
+		}
+
+		vi, err := resolveObjIndex(parts[0], len(of.Vertices)-1)
+		if err != nil {
+			return err
+		}
+		vertexIndices = append(vertexIndices, vi)
+
+		// NB some exporters write texture indices that don't exist, so unless parsing strictly,
+		// those are skipped (and the face has no UVs), rather than the whole face.
+		if len(parts) > 1 && parts[1] != "" {
+			ti, err := resolveObjIndex(parts[1], len(of.TextureVertices)-1)
+			if err == nil {
+				faceUVs = append(faceUVs, of.TextureVertices[ti])
+			} else if of.strict {
+				return err
+			}
+		}
+		if len(parts) > 2 && parts[2] != "" {
+			ni, err := resolveObjIndex(parts[2], len(of.Normals)-1)
+			if err != nil {
+				return err
+			}
+			faceNormals = append(faceNormals, of.Normals[ni])
+		}
+	}
+	if len(faceUVs) != len(vertexIndices) {
+		faceUVs = nil
+	}
+	if len(faceNormals) != len(vertexIndices) {
+		faceNormals = nil
+	}
+
+	of.addFace(vertexIndices, faceNormals, faceUVs)
+	return nil
+}
+
+// Adds the triangles of a face to the current group, with the current material. Faces
+// without normals that need them generated are only triangulated at the end.
+func (of *ObjFile) addFace(vertexIndices []int, faceNormals, faceUVs []Tuple) {
+	material := of.Materials[of.CurrentMaterialName]
+	if len(faceNormals) == 0 && (of.SmoothingGroup != "" || of.options.SmoothNormals) {
+		of.smoothFaces = append(of.smoothFaces, objFace{vertexIndices, faceUVs, of.currentGroup(), material, of.SmoothingGroup})
+		return
+	}
+
+	faceVertices := make([]Tuple, len(vertexIndices))
+	for idx, vi := range vertexIndices {
+		faceVertices[idx] = of.Vertices[vi]
+	}
+	of.addPolygon(of.currentGroup(), material, vertexIndices, faceVertices, faceNormals, faceUVs)
+}
+
+// Returns the current group, creating it (in the current object, if there is one) if it's new.
+func (of *ObjFile) currentGroup() *Shape {
+	if g, found := of.Groups[of.CurrentGroupName]; found {
+		return g
+	}
+	g := NewGroup()
+	of.Groups[of.CurrentGroupName] = g
+	if of.CurrentObjectName != "" {
+		of.Objects[of.CurrentObjectName].AddChildren(g)
+		of.objectGroups[of.CurrentGroupName] = true
+	}
+	return g
+}
+
+// Adds a face (a polygon, with the points at vertexIndices) to a group with the given material:
+// its triangles (see addTriangles), or with ObjOptions.Meshes, the group's Mesh with that
+// material (which don't have VertexColors).
+func (of *ObjFile) addPolygon(g *Shape, material *Material, vertexIndices []int, faceVertices, faceNormals, faceUVs []Tuple) {
+	if !of.options.Meshes {
+		of.addTriangles(g, material, vertexIndices, fanTriangulation(faceVertices, faceNormals, faceUVs))
+		return
+	}
+
+	var m *objMesh
+	if count := len(of.meshes); count > 0 && of.meshes[count-1].group == g && of.meshes[count-1].material == material {
+		m = of.meshes[count-1] // most faces are in the same group as the last one
+	} else {
+		for _, existing := range of.meshes {
+			if existing.group == g && existing.material == material {
+				m = existing
+			}
+		}
+	}
+	if m == nil {
+		m = &objMesh{group: g, material: material, pointIndices: map[int]int{}, normalIndex: map[Tuple]int{}, uvIndex: map[Tuple]int{}}
+		of.meshes = append(of.meshes, m)
+	}
+
+	// Returns the index of a tuple in a mesh's points, normals or UVs, adding it if it's not there yet.
+	indexOf := func(tuples *[]Tuple, index map[Tuple]int, t Tuple) int {
+		idx, found := index[t]
+		if !found {
+			*tuples = append(*tuples, t)
+			idx = len(*tuples) - 1
+			index[t] = idx
+		}
+		return idx
+	}
+
+	points := make([]int, len(vertexIndices))
+	for idx, vi := range vertexIndices {
+		pi, found := m.pointIndices[vi]
+		if !found {
+			m.points = append(m.points, of.Vertices[vi])
+			pi = len(m.points) - 1
+			m.pointIndices[vi] = pi
+		}
+		points[idx] = pi
+	}
+	for idx := 0; idx < len(points)-2; idx++ {
+		corners := [3]int{0, idx + 1, idx + 2}
+		m.triangles = append(m.triangles, [3]int{points[0], points[idx+1], points[idx+2]})
+
+		normals, uvs := [3]int{-1, -1, -1}, [3]int{-1, -1, -1}
+		for c, corner := range corners {
+			if len(faceNormals) > 0 {
+				normals[c] = indexOf(&m.normals, m.normalIndex, faceNormals[corner])
+			}
+			if len(faceUVs) > 0 {
+				uvs[c] = indexOf(&m.uvs, m.uvIndex, faceUVs[corner])
+			}
+		}
+		m.normalIndices = append(m.normalIndices, normals)
+		m.uvIndices = append(m.uvIndices, uvs)
+	}
+}
+
+// Triangulates the faces that were left until the end (see triangulateSmoothFaces), and
+// turns the faces for Meshes into Meshes (see ObjOptions.Meshes).
+func (of *ObjFile) finish() {
+	of.triangulateSmoothFaces()
+
+	for _, m := range of.meshes {
+		s := NewMeshWithOptions(m.points, m.triangles, of.options.BVH)
+		mesh := s.LocalShape.(*Mesh)
+
+		// If some of the triangles have normals (or UVs), the others get ones that make
+		// them the same as without: their flat normal (or barycentric coordinates).
+		if len(m.normals) > 0 {
+			for idx, normals := range m.normalIndices {
+				if normals[0] < 0 {
+					_, e1, e2 := mesh.trianglePoints(idx)
+					normal := e2.Cross(e1)
+					if normal.Magnitude() > EPSILON {
+						normal = normal.Normalized()
 					}
+					m.normals = append(m.normals, normal)
+					n := len(m.normals) - 1
+					m.normalIndices[idx] = [3]int{n, n, n}
 				}
 			}
-
-			for _, tri := range fanTriangulation(faceVertices, faceNormals) {
-				tri.Material.Color = Colors["Red"]
-				g := of.Groups[of.CurrentGroupName]
-				g.AddChildren(tri)
+			mesh.Normals, mesh.NormalIndices = m.normals, m.normalIndices
+		}
+		if len(m.uvs) > 0 {
+			barycentric := [3]int{len(m.uvs), len(m.uvs) + 1, len(m.uvs) + 2}
+			m.uvs = append(m.uvs, NewPoint(0, 0, 0), NewPoint(1, 0, 0), NewPoint(0, 1, 0))
+			for idx, uvs := range m.uvIndices {
+				if uvs[0] < 0 {
+					m.uvIndices[idx] = barycentric
+				}
 			}
-			continue
+			mesh.UVs, mesh.UVIndices = m.uvs, m.uvIndices
 		}
 
-		// Scan for groups
-		if _, err := fmt.Sscanf(line, "g %s", &g); err == nil {
-			of.CurrentGroupName = g
-			if _, found := of.Groups[g]; !found {
-				group := NewGroup()
-				of.Groups[g] = group
+		if m.material != nil {
+			s.Material = m.material
+		} else {
+			s.Material.Color = Colors["Red"]
+		}
+		m.group.AddChildren(s)
+	}
+	of.meshes = nil
+}
+
+// Adds the triangles of a face (from fanTriangulation) to a group with the given material
+// (or red, if it's nil), unless there are VertexColors: then each triangle's color blends
+// the colors of its points, with a VertexColorPattern.
+func (of *ObjFile) addTriangles(g *Shape, material *Material, vertexIndices []int, triangles []*Shape) {
+	for idx, tri := range triangles {
+		if of.VertexColors != nil {
+			m := DefaultMaterial()
+			if material != nil {
+				*m = *material
 			}
-			continue
+			c1, c2, c3 := of.VertexColors[vertexIndices[0]], of.VertexColors[vertexIndices[idx+1]], of.VertexColors[vertexIndices[idx+2]]
+			if c1.IsEqualTo(c2) && c1.IsEqualTo(c3) {
+				m.Color = c1
+			} else {
+				m.Pattern = NewShapeTextureMapPattern(NewVertexColorPattern(c1, c2, c3))
+			}
+			tri.Material = m
+		} else if material != nil {
+			tri.Material = material
+		} else {
+			tri.Material.Color = Colors["Red"]
 		}
+	}
+	g.AddChildren(triangles...)
+}
 
-		of.IgnoredLineCount += 1
+// Creates the triangles of faces in smoothing groups, with the normal at each vertex
+// averaged from the (weighted) normals of the faces in the same group around it, except
+// for faces at a sharper angle than the CreaseAngle (see ObjOptions).
+func (of *ObjFile) triangulateSmoothFaces() {
+	type smoothVertex struct {
+		smoothingGroup string
+		vertexIdx      int
+	}
+	type corner struct {
+		faceIdx  int
+		position int // in the face's vertexIndices
 	}
 
-	// fmt.Printf("The current group %s has %d children\n", of.CurrentGroupName, len(of.Groups[of.CurrentGroupName].LocalShape.(Group).Children))
-	return of
+	// Find the normal of each face (as long as twice its area), and the faces around each vertex.
+	faceNormals := make([]Tuple, len(of.smoothFaces))
+	unitNormals := make([]Tuple, len(of.smoothFaces))
+	corners := map[smoothVertex][]corner{}
+	for faceIdx, face := range of.smoothFaces {
+		p1 := of.Vertices[face.vertexIndices[0]]
+		normal := NewVector(0, 0, 0)
+		for idx := 1; idx < len(face.vertexIndices)-1; idx++ {
+			e1 := of.Vertices[face.vertexIndices[idx]].Subtract(p1)
+			e2 := of.Vertices[face.vertexIndices[idx+1]].Subtract(p1)
+			normal = normal.Add(e2.Cross(e1)) // same winding as Triangle.Normal
+		}
+		faceNormals[faceIdx] = normal
+		unitNormals[faceIdx] = NewVector(0, 0, 0) // for degenerate faces, which don't count
+		if normal.Magnitude() > EPSILON {
+			unitNormals[faceIdx] = normal.Normalized()
+		}
+
+		for position, vertexIdx := range face.vertexIndices {
+			key := smoothVertex{face.smoothingGroup, vertexIdx}
+			corners[key] = append(corners[key], corner{faceIdx, position})
+		}
+	}
+
+	// How much a face counts towards the normal at one of its corners.
+	weightedNormal := func(c corner) Tuple {
+		if of.options.Weighting != AngleWeighting {
+			return faceNormals[c.faceIdx]
+		}
+		indices := of.smoothFaces[c.faceIdx].vertexIndices
+		count := len(indices)
+		p := of.Vertices[indices[c.position]]
+		toPrevious := of.Vertices[indices[(c.position+count-1)%count]].Subtract(p)
+		toNext := of.Vertices[indices[(c.position+1)%count]].Subtract(p)
+		cos := toPrevious.Dot(toNext) / (toPrevious.Magnitude() * toNext.Magnitude())
+		return unitNormals[c.faceIdx].Multiply(math.Acos(math.Max(-1, math.Min(1, cos))))
+	}
+
+	minimumCos := -1.0 // i.e. no crease angle
+	if of.options.CreaseAngle > 0 {
+		minimumCos = math.Cos(of.options.CreaseAngle)
+	}
+
+	for faceIdx, face := range of.smoothFaces {
+		vertices := make([]Tuple, len(face.vertexIndices))
+		vertexNormals := make([]Tuple, len(face.vertexIndices))
+		for position, vertexIdx := range face.vertexIndices {
+			vertices[position] = of.Vertices[vertexIdx]
+
+			normal := NewVector(0, 0, 0)
+			for _, c := range corners[smoothVertex{face.smoothingGroup, vertexIdx}] {
+				if c.faceIdx == faceIdx || unitNormals[c.faceIdx].Dot(unitNormals[faceIdx]) >= minimumCos-EPSILON {
+					normal = normal.Add(weightedNormal(c))
+				}
+			}
+			if normal.Magnitude() < EPSILON { // e.g. faces that cancel each other out
+				normal = unitNormals[faceIdx]
+			}
+			if normal.Magnitude() > EPSILON {
+				normal = normal.Normalized()
+			}
+			vertexNormals[position] = normal
+		}
+		of.addPolygon(face.group, face.material, face.vertexIndices, vertices, vertexNormals, face.uvs)
+	}
+	of.smoothFaces = nil
 }
 
 // Given a list of vertex indices, where the indices represent
@@ -128,22 +631,30 @@ func ParseObjFile(s string) ObjFile {
 //         \  \     /
 //          \   \  /
 //           e----d
-// And return the list of triangles
-func fanTriangulation(faceVertices, faceNormals []Tuple) []*Shape {
+// And return the list of triangles (with UVs, if faceUVs isn't empty)
+func fanTriangulation(faceVertices, faceNormals, faceUVs []Tuple) []*Shape {
 	triangles := []*Shape{}
 	for idx := 0; idx < len(faceVertices)-2; idx++ {
+		uv1, uv2, uv3 := NewPoint(0, 0, 0), NewPoint(1, 0, 0), NewPoint(0, 1, 0)
+		if len(faceUVs) > 0 {
+			uv1, uv2, uv3 = faceUVs[0], faceUVs[idx+1], faceUVs[idx+2]
+		}
+
 		var tri *Shape
 		if len(faceNormals) > 0 {
-			tri = NewSmoothTriangle(
+			tri = NewSmoothTriangleWithUVs(
 				faceVertices[0],
 				faceVertices[idx+1],
 				faceVertices[idx+2],
 				faceNormals[0],
 				faceNormals[idx+1],
 				faceNormals[idx+2],
+				uv1,
+				uv2,
+				uv3,
 			)
 		} else {
-			tri = NewTriangle(faceVertices[0], faceVertices[idx+1], faceVertices[idx+2])
+			tri = NewTriangleWithUVs(faceVertices[0], faceVertices[idx+1], faceVertices[idx+2], uv1, uv2, uv3)
 		}
 		triangles = append(triangles, tri)
 	}
@@ -1,7 +1,11 @@
 package raytracer
 
 import (
+	"fmt"
 	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -170,3 +174,228 @@ f 1/0/3 2/102/1 3/14/2`
 	assertEqualTuple(t, parser.Normals[2], t1.N3)
 	assertEqualShape(t, *shape1, *shape2)
 }
+
+func TestTextureVertexRecords(t *testing.T) {
+	file := `vt 0 0.5
+vt 1 0.25 0
+vt 0.5 1`
+	parser := ParseObjFile(file)
+
+	assertEqualTuple(t, NewPoint(0, 0.5, 0), parser.TextureVertices[1])
+	assertEqualTuple(t, NewPoint(1, 0.25, 0), parser.TextureVertices[2])
+	assertEqualTuple(t, NewPoint(0.5, 1, 0), parser.TextureVertices[3])
+}
+
+func TestFacesWithTextureCoordinates(t *testing.T) {
+	file := `v 0 1 0
+v -1 0 0
+v 1 0 0
+vt 0.5 1
+vt 0 0
+vt 1 0
+vn 0 0 -1
+f 1/1 2/2 3/3
+f 1/1/1 2/2/1 3/3/1`
+
+	parser := ParseObjFile(file)
+	g := parser.DefaultGroup.LocalShape.(Group)
+
+	t1 := g.Children[0].LocalShape.(*Triangle)
+	t2 := g.Children[1].LocalShape.(*SmoothTriangle)
+
+	assertEqualTuple(t, parser.TextureVertices[1], t1.UV1)
+	assertEqualTuple(t, parser.TextureVertices[2], t1.UV2)
+	assertEqualTuple(t, parser.TextureVertices[3], t1.UV3)
+	assertEqualTuple(t, parser.TextureVertices[1], t2.UV1)
+	assertEqualTuple(t, parser.TextureVertices[2], t2.UV2)
+	assertEqualTuple(t, parser.TextureVertices[3], t2.UV3)
+}
+
+func TestLoadingAnOBJFileWithMaterials(t *testing.T) {
+	dir := t.TempDir()
+	obj := `mtllib shapes.mtl
+v 0 1 0
+v -1 0 0
+v 1 0 0
+usemtl green
+f 1 2 3
+usemtl missing
+f 1 2 3`
+	mtl := `newmtl green
+Kd 0 1 0`
+	if err := ioutil.WriteFile(filepath.Join(dir, "shapes.obj"), []byte(obj), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "shapes.mtl"), []byte(mtl), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := LoadObjFile(filepath.Join(dir, "shapes.obj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := parser.DefaultGroup.LocalShape.(Group)
+
+	assertEqualInt(t, 1, len(parser.MaterialLibraries))
+	assertEqualColor(t, Colors["Green"], g.Children[0].Material.Color)
+	assertEqualColor(t, Colors["Red"], g.Children[1].Material.Color)
+}
+
+func TestParseObjWithRelativeIndices(t *testing.T) {
+	file := `v 0 1 0
+v -1 0 0
+v 1 0 0
+f -3 -2 -1`
+	parser, err := ParseObj(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tri := parser.DefaultGroup.LocalShape.(Group).Children[0].LocalShape.(*Triangle)
+
+	assertEqualTuple(t, parser.Vertices[1], tri.P1)
+	assertEqualTuple(t, parser.Vertices[2], tri.P2)
+	assertEqualTuple(t, parser.Vertices[3], tri.P3)
+}
+
+func TestParseObjReturnsErrorsWithLineNumbers(t *testing.T) {
+	testCases := []struct {
+		file  string
+		error string
+	}{
+		{"v 0 1 0\nv 1 0 0\nf 1 2 3", "OBJ line 3: Index 3 is out of range (there are 2)\n"},
+		{"v 0 1 0\nv 1 0 0\nf 1 2 -3", "OBJ line 3: Index -3 is out of range (there are 2)\n"},
+		{"# a comment\nv 0 one 0", "OBJ line 2: Cannot convert one to float64\n"},
+		{"vn 0 1", "OBJ line 1: Expected 3 numbers, but got 2\n"},
+		{"v 0 1 0\nf 1 1", "OBJ line 2: Face needs at least 3 vertices, but has 2\n"},
+		{"v 0 1 0\nv 1 0 0\nv 0 0 1\nvt 0 0\nf 1/1 2/2 3/1", "OBJ line 5: Index 2 is out of range (there are 1)\n"},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			_, err := ParseObj(strings.NewReader(tc.file))
+			if err == nil || err.Error() != tc.error {
+				t.Errorf("Expected error %q, but got %v", tc.error, err)
+			}
+		})
+	}
+}
+
+func TestParseObjFileSkipsTextureIndicesThatDontExist(t *testing.T) {
+	parser := ParseObjFile("v 0 1 0\nv 1 0 0\nv 0 0 1\nvt 0 0\nf 1/1 2/2 3/1")
+	children := parser.DefaultGroup.LocalShape.(Group).Children
+
+	assertEqualInt(t, 0, parser.IgnoredLineCount)
+	assertEqualInt(t, 1, len(children))
+	assertEqualString(t, "Triangle", children[0].LocalShape.localType()) // i.e. without UVs
+}
+
+func TestParseObjPutsGroupsInObjects(t *testing.T) {
+	file := `v 0 1 0
+v -1 0 0
+v 1 0 0
+o Thing
+f 1 2 3
+g Part
+f 1 2 3
+o Other
+f 1 2 3`
+	parser, err := ParseObj(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqualInt(t, 2, len(parser.Objects))
+	thing := parser.Objects["Thing"]
+	assertEqualInt(t, 2, len(thing.LocalShape.(Group).Children))
+	if !thing.Includes(parser.Groups["Part"]) {
+		t.Errorf("Expected object Thing to include group Part")
+	}
+	assertEqualInt(t, 0, len(parser.DefaultGroup.LocalShape.(Group).Children))
+
+	g := parser.ToGroup()
+	assertEqualInt(t, 3, len(g.LocalShape.(Group).Children)) // the default group and both objects
+}
+
+func TestParseObjGeneratesNormalsForSmoothingGroups(t *testing.T) {
+	file := `v 0 0 0
+v 1 0 0
+v 1 0 1
+v 0 1 0
+s 1
+f 1 2 3
+f 1 4 2
+s off
+f 1 4 2`
+	parser, err := ParseObj(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := parser.DefaultGroup.LocalShape.(Group).Children
+
+	assertEqualInt(t, 3, len(children))
+	_ = children[0].LocalShape.(*Triangle) // not smoothed
+	t1 := children[1].LocalShape.(*SmoothTriangle)
+	t2 := children[2].LocalShape.(*SmoothTriangle)
+
+	// Shared vertices average the two faces' normals, the others just have their face's.
+	assertEqualTuple(t, NewVector(0, math.Sqrt2/2, math.Sqrt2/2), t1.N1)
+	assertEqualTuple(t, NewVector(0, math.Sqrt2/2, math.Sqrt2/2), t1.N2)
+	assertEqualTuple(t, NewVector(0, 1, 0), t1.N3)
+	assertEqualTuple(t, NewVector(0, 0, 1), t2.N2)
+}
+
+func TestParseObjWithOptionsGeneratesNormals(t *testing.T) {
+	file := `v 0 0 0
+v 1 0 0
+v 1 0 1
+v 0 1 0
+f 1 2 3
+f 1 4 2`
+	testCases := []struct {
+		options ObjOptions
+		normal  Tuple // at the first vertex of the first face
+	}{
+		{ObjOptions{SmoothNormals: true}, NewVector(0, math.Sqrt2/2, math.Sqrt2/2)},
+		{ObjOptions{SmoothNormals: true, Weighting: AngleWeighting}, NewVector(0, 1/math.Sqrt(5), 2/math.Sqrt(5))},
+		{ObjOptions{SmoothNormals: true, CreaseAngle: math.Pi / 4}, NewVector(0, 1, 0)},
+		{ObjOptions{SmoothNormals: true, CreaseAngle: math.Pi / 2}, NewVector(0, math.Sqrt2/2, math.Sqrt2/2)},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			parser, err := ParseObjWithOptions(strings.NewReader(file), tc.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tri := parser.DefaultGroup.LocalShape.(Group).Children[0].LocalShape.(*SmoothTriangle)
+			assertEqualTuple(t, tc.normal, tri.N1)
+		})
+	}
+}
+
+/////////////
+// Benchmarks
+/////////////
+
+func BenchmarkParseObj(b *testing.B) {
+	// A 300x300 grid of squares, i.e. 180,000 triangles.
+	var sb strings.Builder
+	size := 300
+	for z := 0; z <= size; z++ {
+		for x := 0; x <= size; x++ {
+			fmt.Fprintf(&sb, "v %d 0 %d\n", x, z)
+		}
+	}
+	for z := 0; z < size; z++ {
+		for x := 0; x < size; x++ {
+			i := z*(size+1) + x + 1
+			fmt.Fprintf(&sb, "f %d %d %d %d\n", i, i+1, i+size+2, i+size+1)
+		}
+	}
+	file := sb.String()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseObj(strings.NewReader(file)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
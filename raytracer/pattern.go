@@ -48,16 +48,93 @@ func (p *Pattern) IsEqualTo(p2 *Pattern) bool {
 }
 
 func (p *Pattern) PatternAtShape(s *Shape, worldPoint Tuple) Color {
+	if tm, ok := p.LocalPattern.(TextureMapPattern); ok && tm.UVMap == nil {
+		if u, v, hasUV := s.UVAt(worldPoint, nil); hasUV {
+			return tm.Pattern.UVPatternAt(u, v)
+		}
+	}
+
 	objectPoint := s.WorldToObject(worldPoint)
 	patternPoint := p.InverseTransform.MultiplyByTuple(objectPoint)
 
 	return p.LocalPattern.LocalPatternAt(patternPoint)
 }
 
+// Patterns that look different depending on how much of them is seen at once (e.g.
+// textures, which are blurred when far away, to avoid aliasing) can implement this
+// in addition to PatternInterface. The footprint is how wide the area around the
+// point is, in the pattern's space.
+type FootprintPatternInterface interface {
+	LocalPatternAtFootprint(point Tuple, footprint float64) Color
+}
+
+// Same as FootprintPatternInterface, but for UV patterns (see TextureMapPattern), where
+// the footprint is how wide the area is in u and v.
+type UVFootprintPatternInterface interface {
+	LocalUVPatternAtFootprint(u, v, footprint float64) Color
+}
+
+// Same as PatternAtShape, but for an area around the point that's footprint wide
+// (see Computation.Footprint), if the pattern is a FootprintPatternInterface.
+func (p *Pattern) PatternAtShapeWithFootprint(s *Shape, worldPoint Tuple, footprint float64) Color {
+	fp, ok := p.LocalPattern.(FootprintPatternInterface)
+	if !ok || footprint <= 0 {
+		return p.PatternAtShape(s, worldPoint)
+	}
+
+	toPatternSpace := func(point Tuple) Tuple {
+		return p.InverseTransform.MultiplyByTuple(s.WorldToObject(point))
+	}
+	patternPoint := toPatternSpace(worldPoint)
+
+	// Find how big the footprint is in the pattern's space, on average over each axis.
+	patternFootprint := 0.0
+	for _, axis := range []Tuple{NewVector(1, 0, 0), NewVector(0, 1, 0), NewVector(0, 0, 1)} {
+		offset := toPatternSpace(worldPoint.Add(axis.Multiply(footprint)))
+		patternFootprint += offset.Subtract(patternPoint).Magnitude() / 3
+	}
+
+	return fp.LocalPatternAtFootprint(patternPoint, patternFootprint)
+}
+
+// Same as PatternAtShapeWithFootprint, but for the point and footprint of a computation,
+// using the shape's own texture coordinates there for TextureMapPatterns without a UVMap.
+func (p *Pattern) patternAtComputation(c *Computation) Color {
+	if tm, ok := p.LocalPattern.(TextureMapPattern); ok && tm.UVMap == nil && c.HasUV {
+		return tm.shapeUVPatternAt(c.Object, c.OverPoint, c.TexU, c.TexV, c.Footprint)
+	}
+	return p.PatternAtShapeWithFootprint(c.Object, c.OverPoint, c.Footprint)
+}
+
+// Returns the pattern's color at a point that isn't on a shape (e.g. a direction
+// for World.Background), applying only the pattern's own transformation.
+func (p *Pattern) PatternAt(point Tuple) Color {
+	patternPoint := p.InverseTransform.MultiplyByTuple(point)
+
+	return p.LocalPattern.LocalPatternAt(patternPoint)
+}
+
 func (p *Pattern) UVPatternAt(u, v float64) Color {
 	return p.LocalPattern.LocalUVPatternAt(u, v)
 }
 
+// Returns the color of a pattern's slot (e.g. StripePattern's A) at a point in the
+// pattern's space: the nested pattern's color if there is one, otherwise c.
+func colorOrPatternAt(c Color, p *Pattern, point Tuple) Color {
+	if p == nil {
+		return c
+	}
+	return p.PatternAt(point)
+}
+
+// Same as Pattern.IsEqualTo, but also for slots that may not have a pattern.
+func patternsAreEqual(p1, p2 *Pattern) bool {
+	if p1 == nil || p2 == nil {
+		return p1 == p2
+	}
+	return p1.IsEqualTo(p2)
+}
+
 // TODO: is there a better value to return than string? Maybe enum/iota?
 // Returns which face a given point on a unit cube is on.
 func FaceFromPoint(p Tuple) string {
@@ -530,6 +530,353 @@ func TestCheckerPatternIn2d(t *testing.T) {
 	}
 }
 
+func TestUVImagePatternWithBilinearFilteringBlendsNeighboringPixels(t *testing.T) {
+	c := NewCanvas(2, 1)
+	c.WritePixel(0, 0, Colors["Black"])
+	c.WritePixel(1, 0, Colors["White"])
+	testCases := []struct {
+		u     float64
+		wrap  string
+		color Color
+	}{
+		{0.25, WrapRepeat, Colors["Black"]},
+		{0.5, WrapRepeat, NewColor(0.5, 0.5, 0.5)},
+		{0.75, WrapRepeat, Colors["White"]},
+		{1, WrapRepeat, NewColor(0.5, 0.5, 0.5)},
+		{1, WrapClamp, Colors["White"]},
+		{1.25, WrapMirror, Colors["White"]},
+		{1.75, WrapMirror, Colors["Black"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			pattern := NewFilteredUVImagePattern(c, FilterBilinear, tc.wrap)
+			assertEqualColor(t, tc.color, pattern.UVPatternAt(tc.u, 0.5))
+		})
+	}
+}
+
+func TestUVImagePatternWithBicubicFilteringPassesThroughPixelCenters(t *testing.T) {
+	c := NewCanvas(4, 1)
+	for x := 0; x < 4; x++ {
+		c.WritePixel(x, 0, NewColor(float64(x)/3, 0, 0))
+	}
+	pattern := NewFilteredUVImagePattern(c, FilterBicubic, WrapClamp)
+
+	assertEqualColor(t, NewColor(1.0/3, 0, 0), pattern.UVPatternAt(0.375, 0.5))
+	assertEqualColor(t, NewColor(0.5, 0, 0), pattern.UVPatternAt(0.5, 0.5))
+}
+
+func TestUVImagePatternIsAveragedForLargeFootprints(t *testing.T) {
+	c := NewCanvas(4, 4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				c.WritePixel(x, y, Colors["White"])
+			}
+		}
+	}
+	pattern := NewFilteredUVImagePattern(c, FilterNearest, WrapRepeat)
+	ip := pattern.LocalPattern.(UVImagePattern)
+
+	assertEqualColor(t, Colors["Black"], ip.LocalUVPatternAtFootprint(0, 0, 0))
+	assertEqualColor(t, Colors["Black"], ip.LocalUVPatternAtFootprint(0, 0, 0.1))
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), ip.LocalUVPatternAtFootprint(0, 0, 0.5))
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), ip.LocalUVPatternAtFootprint(0, 0, 10))
+}
+
+func TestTextureMapPatternPassesFootprintToUVImagePattern(t *testing.T) {
+	c := NewCanvas(4, 4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				c.WritePixel(x, y, Colors["White"])
+			}
+		}
+	}
+	pattern := NewTextureMapPattern(NewFilteredUVImagePattern(c, FilterNearest, WrapRepeat), PlanarMap)
+	s := NewPlane()
+
+	assertEqualColor(t, Colors["Black"], pattern.PatternAtShapeWithFootprint(s, NewPoint(0, 0, 0), 0))
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), pattern.PatternAtShapeWithFootprint(s, NewPoint(0, 0, 0), 0.5))
+}
+
+func TestAShapeTextureMapPatternUsesTheShapesUVs(t *testing.T) {
+	shape := NewTriangleWithUVs(
+		NewPoint(0, 1, 0), NewPoint(-1, 0, 0), NewPoint(1, 0, 0),
+		NewPoint(0, 0, 0), NewPoint(0, 0, 0), NewPoint(1, 0, 0),
+	)
+	pattern := NewShapeTextureMapPattern(NewUVCheckerPattern(2, 2, Colors["Black"], Colors["White"]))
+	r := NewRay(NewPoint(0.5, 0.25, -2), NewVector(0, 0, 1))
+	xs := shape.Intersect(r)
+	assertEqualBool(t, true, xs[0].HasUV)
+	assertEqualFloat64(t, 0.625, xs[0].TexU)
+
+	hit := *xs[0]
+	c := xs[0].PrepareComputations(r)
+	assertEqualFloat64(t, 0.625, c.TexU)
+	assert(t, hit == *xs[0]) // preparing the computations doesn't change the intersection
+	assertEqualColor(t, Colors["White"], pattern.PatternAtShape(shape, c.Point))
+	assertEqualColor(t, Colors["White"], pattern.patternAtComputation(c))
+}
+
+func TestAVertexColorPatternBlendsTheColorsOfATrianglesPoints(t *testing.T) {
+	shape := NewTriangle(NewPoint(0, 1, 0), NewPoint(-1, 0, 0), NewPoint(1, 0, 0))
+	pattern := NewShapeTextureMapPattern(NewVertexColorPattern(Colors["Red"], Colors["Green"], Colors["Blue"]))
+
+	assertEqualColor(t, Colors["Red"], pattern.PatternAtShape(shape, NewPoint(0, 1, 0)))
+	assertEqualColor(t, Colors["Blue"], pattern.PatternAtShape(shape, NewPoint(1, 0, 0)))
+	assertEqualColor(t, NewColor(0, 0.5, 0.5), pattern.PatternAtShape(shape, NewPoint(0, 0, 0)))
+}
+
+func TestASolidPatternIsTheSameEverywhere(t *testing.T) {
+	pattern := NewSolidPattern(Colors["Red"])
+
+	assertEqualColor(t, Colors["Red"], pattern.LocalPattern.LocalPatternAt(NewPoint(0, 0, 0)))
+	assertEqualColor(t, Colors["Red"], pattern.LocalPattern.LocalPatternAt(NewPoint(-3, 5, 100)))
+	assertEqualColor(t, Colors["Red"], pattern.UVPatternAt(0.3, 0.7))
+}
+
+func TestAVerticalGradientIsClampedBetweenBottomAndTop(t *testing.T) {
+	pattern := NewVerticalGradientPattern(Colors["Black"], Colors["White"])
+	testCases := []struct {
+		point  Tuple
+		result Color
+	}{
+		{NewPoint(0, -2, 0), Colors["Black"]},
+		{NewPoint(0, -1, 0), Colors["Black"]},
+		{NewPoint(5, 0, 5), NewColor(0.5, 0.5, 0.5)},
+		{NewPoint(0, 0.5, 0), NewColor(0.75, 0.75, 0.75)},
+		{NewPoint(0, 2, 0), Colors["White"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, pattern.LocalPattern.LocalPatternAt(tc.point))
+		})
+	}
+}
+
+func TestPatternAtAppliesOnlyThePatternTransformation(t *testing.T) {
+	pattern := NewTestPattern()
+	pattern.SetTransform(NewScale(2, 2, 2))
+
+	assertEqualColor(t, NewColor(0.5, 1, 1.5), pattern.PatternAt(NewPoint(1, 2, 3)))
+}
+
+func TestFindingTheColorsOfACubeMapInADirection(t *testing.T) {
+	red := Colors["Red"]
+	yellow := Colors["Yellow"]
+	brown := Colors["Brown"]
+	green := Colors["Green"]
+	cyan := Colors["Cyan"]
+	blue := Colors["Blue"]
+	purple := Colors["Purple"]
+	white := Colors["White"]
+
+	left := NewUVAlignCheckPattern(yellow, cyan, red, blue, brown)
+	front := NewUVAlignCheckPattern(cyan, red, yellow, brown, green)
+	right := NewUVAlignCheckPattern(red, yellow, purple, green, white)
+	back := NewUVAlignCheckPattern(green, purple, cyan, white, blue)
+	up := NewUVAlignCheckPattern(brown, cyan, purple, red, yellow)
+	down := NewUVAlignCheckPattern(purple, brown, green, blue, white)
+
+	pattern := NewCubeMapPattern(left, front, right, back, up, down)
+
+	// Same points as the ones on the unit cube, but as unnormalized directions.
+	assertEqualColor(t, yellow, pattern.LocalPattern.LocalPatternAt(NewPoint(-10, 0, 0)))
+	assertEqualColor(t, cyan, pattern.LocalPattern.LocalPatternAt(NewPoint(0, 0, 0.5)))
+	assertEqualColor(t, red, pattern.LocalPattern.LocalPatternAt(NewPoint(3, 0, 0)))
+	assertEqualColor(t, green, pattern.LocalPattern.LocalPatternAt(NewPoint(0, 0, -2)))
+	assertEqualColor(t, brown, pattern.LocalPattern.LocalPatternAt(NewPoint(0, 7, 0)))
+	assertEqualColor(t, purple, pattern.LocalPattern.LocalPatternAt(NewPoint(0, -0.1, 0)))
+}
+
+func TestAPerturbedPatternJittersThePoint(t *testing.T) {
+	stripes := NewStripePattern(Colors["White"], Colors["Black"])
+	testCases := []struct {
+		scale float64
+		point Tuple
+	}{
+		{0, NewPoint(0.99, 0.3, 0.7)},
+		{0.5, NewPoint(0, 0, 0)}, // the noise is 0 at integer coordinates
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			p := NewPerturbedPattern(stripes, tc.scale)
+			assertEqualColor(t, stripes.PatternAt(tc.point), p.PatternAt(tc.point))
+		})
+	}
+
+	// Somewhere near a stripe's edge, the jitter moves the point across it.
+	p := NewPerturbedPattern(stripes, 0.5)
+	differs := false
+	for i := 0; i < 100; i++ {
+		point := NewPoint(0.95+float64(i)*0.001, float64(i)*0.037, float64(i)*0.051)
+		if !stripes.PatternAt(point).IsEqualTo(p.PatternAt(point)) {
+			differs = true
+		}
+	}
+	assert(t, differs)
+}
+
+func TestAMarblePatternWithoutTurbulenceIsASineWave(t *testing.T) {
+	p := NewPattern(MarblePattern{A: Colors["Black"], B: Colors["White"], Turbulence: 0, Octaves: 4})
+	testCases := []struct {
+		x      float64
+		result Color
+	}{
+		{0, NewColor(0.5, 0.5, 0.5)},
+		{0.5, Colors["White"]},
+		{1.5, Colors["Black"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, p.PatternAt(NewPoint(tc.x, 0, 0)))
+		})
+	}
+}
+
+func TestAWoodPatternWithoutTurbulenceIsRings(t *testing.T) {
+	p := NewPattern(WoodPattern{A: Colors["Black"], B: Colors["White"], Turbulence: 0, Octaves: 3})
+	testCases := []struct {
+		point  Tuple
+		result Color
+	}{
+		{NewPoint(0, 0, 0), Colors["Black"]},
+		{NewPoint(0.25, 5, 0), NewColor(0.25, 0.25, 0.25)},
+		{NewPoint(0, 0, 1.5), NewColor(0.5, 0.5, 0.5)},
+		{NewPoint(0, 3, 2), Colors["Black"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, p.PatternAt(tc.point))
+		})
+	}
+}
+
+func TestNoisePatternsAreEqual(t *testing.T) {
+	assert(t, NewMarblePattern(Colors["Black"], Colors["White"]).IsEqualTo(NewMarblePattern(Colors["Black"], Colors["White"])))
+	assert(t, !NewWoodPattern(Colors["Black"], Colors["White"]).IsEqualTo(NewWoodPattern(Colors["White"], Colors["Black"])))
+	stripes := NewStripePattern(Colors["White"], Colors["Black"])
+	assert(t, !NewPerturbedPattern(stripes, 0.1).IsEqualTo(NewPerturbedPattern(stripes, 0.2)))
+}
+
+func TestAStripePatternOfPatterns(t *testing.T) {
+	a := NewSolidPattern(Colors["Red"])
+	b := NewStripePattern(Colors["White"], Colors["Black"])
+	b.SetTransform(NewScale(0.5, 0.5, 0.5)) // its own transformation, within the outer stripes
+	p := NewStripePatternFromPatterns(a, b)
+	testCases := []struct {
+		point  Tuple
+		result Color
+	}{
+		{NewPoint(0.5, 0, 0), Colors["Red"]},
+		{NewPoint(1.25, 0, 0), Colors["White"]},
+		{NewPoint(1.75, 0, 0), Colors["Black"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, p.PatternAt(tc.point))
+		})
+	}
+}
+
+func TestAGradientPatternOfPatterns(t *testing.T) {
+	p := NewGradientPatternFromPatterns(
+		NewSolidPattern(Colors["Black"]),
+		NewStripePattern(Colors["White"], Colors["Red"]),
+	)
+
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), p.PatternAt(NewPoint(0.5, 0, 0)))
+	assertEqualColor(t, NewColor(0.5, 0, 0), p.PatternAt(NewPoint(1.5, 0, 0)))
+}
+
+func TestBlendedPatterns(t *testing.T) {
+	a := NewSolidPattern(NewColor(1, 0.5, 0))
+	b := NewSolidPattern(NewColor(0, 0.5, 1))
+	mask := NewStripePattern(Colors["Black"], Colors["White"])
+	testCases := []struct {
+		pattern *Pattern
+		point   Tuple
+		result  Color
+	}{
+		{NewBlendedPattern(a, b, BlendAverage), NewPoint(0, 0, 0), NewColor(0.5, 0.5, 0.5)},
+		{NewBlendedPattern(a, b, BlendMultiply), NewPoint(0, 0, 0), NewColor(0, 0.25, 0)},
+		{NewLerpedPattern(a, b, 0.25), NewPoint(0, 0, 0), NewColor(0.75, 0.5, 0.25)},
+		{NewMaskedPattern(a, b, mask), NewPoint(0.5, 0, 0), NewColor(1, 0.5, 0)},
+		{NewMaskedPattern(a, b, mask), NewPoint(1.5, 0, 0), NewColor(0, 0.5, 1)},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, tc.pattern.PatternAt(tc.point))
+		})
+	}
+}
+
+func TestNestedPatternsAreComparedRecursively(t *testing.T) {
+	nested := func(c Color) *Pattern {
+		return NewCheckerPatternFromPatterns(
+			NewStripePattern(c, Colors["Black"]),
+			NewRingPattern(Colors["White"], Colors["Black"]),
+		)
+	}
+
+	assert(t, NewStripePattern(Colors["White"], Colors["Black"]).IsEqualTo(NewStripePattern(Colors["White"], Colors["Black"])))
+	assert(t, nested(Colors["Red"]).IsEqualTo(nested(Colors["Red"])))
+	assert(t, !nested(Colors["Red"]).IsEqualTo(nested(Colors["Blue"])))
+	assert(t, !nested(Colors["Red"]).IsEqualTo(NewCheckerPattern(Colors["Red"], Colors["Black"])))
+
+	a, b := NewSolidPattern(Colors["Red"]), NewSolidPattern(Colors["Blue"])
+	assert(t, NewBlendedPattern(a, b, BlendAverage).IsEqualTo(NewBlendedPattern(a, b, BlendAverage)))
+	assert(t, !NewBlendedPattern(a, b, BlendAverage).IsEqualTo(NewBlendedPattern(b, a, BlendAverage)))
+}
+
+func TestWorleyPatternIsAFeaturePointsColorAtIt(t *testing.T) {
+	p := NewWorleyPattern(Colors["Black"], Colors["White"], WorleyF1)
+	point := worleyFeaturePoint(2, -3, 5)
+
+	assertEqualColor(t, Colors["Black"], p.PatternAt(point))
+	assertNotEqualTuple(t, NewPoint(2, -3, 5), point)
+	assertEqualBool(t, true, point.X >= 2 && point.X < 3 && point.Y >= -3 && point.Y < -2 && point.Z >= 5 && point.Z < 6)
+}
+
+func TestWorleyDistances(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		point := NewPoint(float64(i)*0.173, float64(i)*-0.091, float64(i)*0.057)
+		f1, f2 := worleyDistances(point)
+		assert(t, f1 <= f2)
+		assert(t, f1 < math.Sqrt(3)) // there's a feature point in the point's own cell
+
+		// F2-F1 is 0 where two cells meet, which is what makes cracks.
+		f1Color := NewWorleyPattern(Colors["Black"], Colors["White"], WorleyF1).PatternAt(point)
+		f2f1Color := NewWorleyPattern(Colors["Black"], Colors["White"], WorleyF2MinusF1).PatternAt(point)
+		assertEqualFloat64(t, math.Min(f1, 1), f1Color.Red)
+		assertEqualFloat64(t, math.Min(f2-f1, 1), f2f1Color.Red)
+	}
+}
+
+func TestARadialGradientPattern(t *testing.T) {
+	p := NewRadialGradientPattern(Colors["White"], Colors["Black"])
+	testCases := []struct {
+		point  Tuple
+		result Color
+	}{
+		{NewPoint(0, 0, 0), Colors["White"]},
+		{NewPoint(0, 5, 0), Colors["White"]},
+		{NewPoint(0.6, 0, 0), NewColor(0.4, 0.4, 0.4)},
+		{NewPoint(0.3, 0, 0.4), NewColor(0.5, 0.5, 0.5)},
+		{NewPoint(3, 0, 0), Colors["Black"]},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			assertEqualColor(t, tc.result, p.PatternAt(tc.point))
+		})
+	}
+
+	// It's scaled like any other pattern.
+	p.SetTransform(NewScale(2, 2, 2))
+	assertEqualColor(t, NewColor(0.5, 0.5, 0.5), p.PatternAt(NewPoint(1, 0, 0)))
+}
+
 /////////////
 // Benchmarks
 /////////////
@@ -0,0 +1,53 @@
+package raytracer
+
+import (
+	"fmt"
+)
+
+// PerturbedPattern jitters each point with PerlinNoise before looking it up in another
+// pattern, so e.g. stripes become wavy and checkers look hand-drawn.
+type PerturbedPattern struct {
+	Pattern *Pattern
+	Scale   float64 // how much the point is moved, at most
+	Octaves int     // how detailed the jitter is (see OctaveNoise)
+}
+
+func NewPerturbedPattern(p *Pattern, scale float64) *Pattern {
+	return NewPattern(PerturbedPattern{Pattern: p, Scale: scale, Octaves: 1})
+}
+
+func (p PerturbedPattern) String() string {
+	return fmt.Sprintf("PerturbedPattern( Pattern: %v Scale: %v Octaves: %v )", p.Pattern, p.Scale, p.Octaves)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p PerturbedPattern) LocalPatternAt(point Tuple) Color {
+	// NB: offset for y and z so that each axis is moved independently.
+	jitter := NewVector(
+		OctaveNoise(point.X, point.Y, point.Z, p.Octaves, 0.5),
+		OctaveNoise(point.X+31.4, point.Y+15.9, point.Z+26.5, p.Octaves, 0.5),
+		OctaveNoise(point.X+35.8, point.Y+97.9, point.Z+32.3, p.Octaves, 0.5),
+	)
+	return p.Pattern.PatternAt(point.Add(jitter.Multiply(p.Scale)))
+}
+
+func (p PerturbedPattern) LocalUVPatternAt(u, v float64) Color {
+	return p.Pattern.UVPatternAt(u, v)
+}
+
+func (p PerturbedPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(PerturbedPattern)
+	if p.Scale != p2Pattern.Scale || p.Octaves != p2Pattern.Octaves {
+		return false
+	}
+	return p.Pattern.IsEqualTo(p2Pattern.Pattern)
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p PerturbedPattern) localType() string {
+	return "PerturbedPattern"
+}
@@ -52,6 +52,11 @@ func (p Plane) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
 	return NewVector(0, 1, 0)
 }
 
+// Fulfills UVShapeInterface, with a PlanarMap (i.e. the texture repeats every unit).
+func (p Plane) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	return PlanarMap(localPoint)
+}
+
 func (p Plane) localIsEqualTo(s2 ShapeInterface) bool {
 	// Other than the transformation, all planes are equal.
 	return true
@@ -0,0 +1,287 @@
+package raytracer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// An element in a PLY file's header, e.g. "element vertex 8" and its properties.
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// A property of a plyElement, e.g. "property float x" or "property list uchar int vertex_indices".
+type plyProperty struct {
+	name      string
+	valueType string
+	countType string // only for lists
+}
+
+// Parses a PLY file (ASCII, or binary in either byte order) into the same structure as an
+// OBJ file: its faces are triangles in DefaultGroup, with the vertices' normals (nx, ny, nz)
+// and colors (red, green, blue, see VertexColors) if they have them. Faces without normals
+// get them generated if options.SmoothNormals is set (see ObjOptions).
+func ParsePly(r io.Reader, options ObjOptions) (*ObjFile, error) {
+	br := bufio.NewReader(r)
+	elements, byteOrder, err := parsePlyHeader(br)
+	if err != nil {
+		return nil, err
+	}
+
+	// NB the rest of the file is read up front, so that the counts of lists can be checked
+	// against how much of it is left, before making room for them.
+	data, err := io.ReadAll(br)
+	if err != nil {
+		return nil, err
+	}
+
+	of := newObjFile(options)
+	var readValue func(valueType string) (float64, error)
+	var remaining func(valueType string) int // how many more values of the type there's room for
+	if byteOrder == nil {
+		fields := strings.Fields(string(data))
+		readValue = func(valueType string) (float64, error) {
+			if len(fields) == 0 {
+				return 0, fmt.Errorf("Unexpected end of PLY file\n")
+			}
+			field := fields[0]
+			fields = fields[1:]
+			f, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return 0, fmt.Errorf("Cannot convert %s to float64\n", field)
+			}
+			return f, nil
+		}
+		remaining = func(valueType string) int {
+			return len(fields)
+		}
+	} else {
+		rest := bytes.NewReader(data)
+		readValue = func(valueType string) (float64, error) {
+			v, err := readBinaryPlyValue(rest, byteOrder, valueType)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return 0, fmt.Errorf("Unexpected end of PLY file\n")
+			}
+			return v, err
+		}
+		remaining = func(valueType string) int {
+			return rest.Len() / plyTypeSize(valueType)
+		}
+	}
+
+	normalIndices := []int{} // the normal of each vertex, if they have normals
+	for _, element := range elements {
+		for idx := 0; idx < element.count; idx++ {
+			values := map[string]float64{}
+			var list []int
+			for _, property := range element.properties {
+				if property.countType == "" {
+					v, err := readValue(property.valueType)
+					if err != nil {
+						return of, err
+					}
+					values[property.name] = v
+					continue
+				}
+
+				count, err := readValue(property.countType)
+				if err != nil {
+					return of, err
+				} else if count < 0 || count != math.Trunc(count) {
+					return of, fmt.Errorf("PLY %s %d has an invalid list count: %v\n", element.name, idx, count)
+				} else if room := remaining(property.valueType); count > float64(room) {
+					return of, fmt.Errorf("PLY %s %d has a list of %v values, but the file only has %d more\n", element.name, idx, count, room)
+				}
+				items := make([]int, int(count))
+				for itemIdx := range items {
+					v, err := readValue(property.valueType)
+					if err != nil {
+						return of, err
+					}
+					items[itemIdx] = int(v)
+				}
+				if property.name == "vertex_indices" || property.name == "vertex_index" {
+					list = items
+				}
+			}
+
+			switch element.name {
+			case "vertex":
+				of.Vertices = append(of.Vertices, NewPoint(values["x"], values["y"], values["z"]))
+				if nx, hasNormal := values["nx"]; hasNormal {
+					of.Normals = append(of.Normals, NewVector(nx, values["ny"], values["nz"]))
+					normalIndices = append(normalIndices, len(of.Normals)-1)
+				}
+				if red, hasColor := values["red"]; hasColor {
+					if of.VertexColors == nil {
+						of.VertexColors = []Color{Colors["Black"]} // first element is dummy, like Vertices
+					}
+					color := NewColor(red, values["green"], values["blue"])
+					if element.colorsAreBytes() {
+						color = color.Divide(255)
+					}
+					of.VertexColors = append(of.VertexColors, color)
+				}
+			case "face":
+				if len(list) < 3 {
+					continue // e.g. a degenerate face
+				}
+				vertexIndices := make([]int, len(list))
+				for itemIdx, vi := range list {
+					if vi < 0 || vi >= len(of.Vertices)-1 {
+						return of, fmt.Errorf("PLY face has vertex index %d, but there are %d vertices\n", vi, len(of.Vertices)-1)
+					}
+					vertexIndices[itemIdx] = vi + 1 // PLY indices start at 0
+				}
+				var faceNormals []Tuple
+				if len(normalIndices) == len(of.Vertices)-1 {
+					faceNormals = make([]Tuple, len(vertexIndices))
+					for itemIdx, vi := range vertexIndices {
+						faceNormals[itemIdx] = of.Normals[normalIndices[vi-1]]
+					}
+				}
+				of.addFace(vertexIndices, faceNormals, nil)
+			}
+		}
+	}
+
+	of.finish()
+	return of, nil
+}
+
+// Are the element's colors bytes (0-255), rather than floats (0-1)?
+func (e plyElement) colorsAreBytes() bool {
+	for _, property := range e.properties {
+		if property.name == "red" {
+			return property.valueType == "uchar" || property.valueType == "uint8"
+		}
+	}
+	return false
+}
+
+// Parses the header of a PLY file, returning its elements and the byte order of the data
+// (or nil if it's ASCII).
+func parsePlyHeader(br *bufio.Reader) ([]plyElement, binary.ByteOrder, error) {
+	var byteOrder binary.ByteOrder
+	elements := []plyElement{}
+
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, nil, fmt.Errorf("PLY header has no end_header\n")
+		}
+		fields := strings.Fields(line)
+		if lineNumber == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return nil, nil, fmt.Errorf("Not a PLY file\n")
+			}
+			continue
+		} else if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return nil, nil, fmt.Errorf("PLY header line %d: format needs a type\n", lineNumber)
+			}
+			switch fields[1] {
+			case "ascii":
+				byteOrder = nil
+			case "binary_little_endian":
+				byteOrder = binary.LittleEndian
+			case "binary_big_endian":
+				byteOrder = binary.BigEndian
+			default:
+				return nil, nil, fmt.Errorf("PLY header line %d: unknown format %s\n", lineNumber, fields[1])
+			}
+		case "element":
+			if len(fields) < 3 {
+				return nil, nil, fmt.Errorf("PLY header line %d: element needs a name and count\n", lineNumber)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil {
+				return nil, nil, fmt.Errorf("PLY header line %d: cannot convert %s to int\n", lineNumber, fields[2])
+			}
+			elements = append(elements, plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return nil, nil, fmt.Errorf("PLY header line %d: property before any element\n", lineNumber)
+			}
+			element := &elements[len(elements)-1]
+			if len(fields) == 5 && fields[1] == "list" {
+				element.properties = append(element.properties, plyProperty{name: fields[4], valueType: fields[3], countType: fields[2]})
+			} else if len(fields) == 3 {
+				element.properties = append(element.properties, plyProperty{name: fields[2], valueType: fields[1]})
+			} else {
+				return nil, nil, fmt.Errorf("PLY header line %d: invalid property\n", lineNumber)
+			}
+		case "end_header":
+			return elements, byteOrder, nil
+		}
+		// Anything else (e.g. comment or obj_info) is ignored.
+	}
+}
+
+// Returns how many bytes a value of a PLY type takes up in a binary file.
+func plyTypeSize(valueType string) int {
+	switch valueType {
+	case "short", "int16", "ushort", "uint16":
+		return 2
+	case "int", "int32", "uint", "uint32", "float", "float32":
+		return 4
+	case "double", "float64":
+		return 8
+	default:
+		return 1 // i.e. char and uchar (and unknown types, which readBinaryPlyValue rejects)
+	}
+}
+
+// Reads one value of a binary PLY file.
+func readBinaryPlyValue(r io.Reader, byteOrder binary.ByteOrder, valueType string) (float64, error) {
+	var err error
+	switch valueType {
+	case "char", "int8":
+		var v int8
+		err = binary.Read(r, byteOrder, &v)
+		return float64(v), err
+	case "uchar", "uint8":
+		var v uint8
+		err = binary.Read(r, byteOrder, &v)
+		return float64(v), err
+	case "short", "int16":
+		var v int16
+		err = binary.Read(r, byteOrder, &v)
+		return float64(v), err
+	case "ushort", "uint16":
+		var v uint16
+		err = binary.Read(r, byteOrder, &v)
+		return float64(v), err
+	case "int", "int32":
+		var v int32
+		err = binary.Read(r, byteOrder, &v)
+		return float64(v), err
+	case "uint", "uint32":
+		var v uint32
+		err = binary.Read(r, byteOrder, &v)
+		return float64(v), err
+	case "float", "float32":
+		var v uint32
+		err = binary.Read(r, byteOrder, &v)
+		return float64(math.Float32frombits(v)), err
+	case "double", "float64":
+		var v uint64
+		err = binary.Read(r, byteOrder, &v)
+		return math.Float64frombits(v), err
+	default:
+		return 0, fmt.Errorf("Unknown PLY type: %s\n", valueType)
+	}
+}
@@ -0,0 +1,93 @@
+package raytracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestParsingAnAsciiPlyFileWithNormalsAndColors(t *testing.T) {
+	file := `ply
+format ascii 1.0
+comment a quad and a triangle
+element vertex 5
+property float x
+property float y
+property float z
+property float nx
+property float ny
+property float nz
+property uchar red
+property uchar green
+property uchar blue
+element face 2
+property list uchar int vertex_indices
+end_header
+0 0 0 0 0 1 255 0 0
+1 0 0 0 0 1 255 0 0
+1 1 0 0 0 1 255 0 0
+0 1 0 0 0 1 255 0 0
+0 2 0 0 0 1 0 0 255
+4 0 1 2 3
+3 2 3 4
+`
+	parser, err := ParsePly(strings.NewReader(file), ObjOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertEqualInt(t, 6, len(parser.Vertices))
+	assertEqualTuple(t, NewPoint(0, 2, 0), parser.Vertices[5])
+	assertEqualColor(t, NewColor(0, 0, 1), parser.VertexColors[5])
+
+	children := parser.DefaultGroup.LocalShape.(Group).Children
+	assertEqualInt(t, 3, len(children))
+	t1 := children[0].LocalShape.(*SmoothTriangle)
+	assertEqualTuple(t, NewPoint(0, 0, 0), t1.P1)
+	assertEqualTuple(t, NewPoint(1, 0, 0), t1.P2)
+	assertEqualTuple(t, NewPoint(1, 1, 0), t1.P3)
+	assertEqualTuple(t, NewVector(0, 0, 1), t1.N1)
+	// The quad's vertices are all red, but the triangle blends to blue at its last vertex.
+	assertEqualColor(t, Colors["Red"], children[0].Material.Color)
+	assertEqualColor(t, NewColor(0.5, 0, 0.5), children[2].Material.Pattern.PatternAtShape(children[2], NewPoint(0.5, 1.5, 0)))
+}
+
+func TestParsingABinaryPlyFile(t *testing.T) {
+	var file bytes.Buffer
+	file.WriteString("ply\nformat binary_little_endian 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list uchar uint vertex_indices\nend_header\n")
+	binary.Write(&file, binary.LittleEndian, []float32{0, 1, 0, -1, 0, 0, 1, 0, 0})
+	binary.Write(&file, binary.LittleEndian, uint8(3))
+	binary.Write(&file, binary.LittleEndian, []uint32{0, 1, 2})
+
+	parser, err := ParsePly(&file, ObjOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	children := parser.DefaultGroup.LocalShape.(Group).Children
+	assertEqualInt(t, 1, len(children))
+	tri := children[0].LocalShape.(*Triangle)
+	assertEqualTuple(t, NewPoint(0, 1, 0), tri.P1)
+	assertEqualTuple(t, NewPoint(-1, 0, 0), tri.P2)
+	assertEqualTuple(t, NewPoint(1, 0, 0), tri.P3)
+	assertEqualColor(t, Colors["Red"], children[0].Material.Color)
+}
+
+func TestParsingAnInvalidPlyFile(t *testing.T) {
+	testCases := []string{
+		"not a ply file\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\n",
+		"ply\nformat ascii 1.0\nelement vertex 1\nproperty float x\nend_header\n",
+		"ply\nformat ascii 1.0\nelement vertex 0\nelement face 1\nproperty list uchar int vertex_indices\nend_header\n3 0 1 2\n",
+		// Lists with counts that are negative or longer than the rest of the file.
+		"ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list int int vertex_indices\nend_header\n0 1 0\n-1 0 0\n1 0 0\n-3 0 1 2\n",
+		"ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list uint int vertex_indices\nend_header\n0 1 0\n-1 0 0\n1 0 0\n4000000000 0 1 2\n",
+		"ply\nformat binary_little_endian 1.0\nelement face 1\nproperty list uint uint vertex_indices\nend_header\n\xff\xff\xff\xff\x00\x00\x00\x00",
+	}
+	for _, tc := range testCases {
+		if _, err := ParsePly(strings.NewReader(tc), ObjOptions{}); err == nil {
+			t.Errorf("Expected an error parsing %q", tc)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// RadialGradientPattern blends from A at the y axis to B at a distance of 1 from it
+// (and stays B beyond that), like a spotlight on the floor. Scale it to change the radius.
+type RadialGradientPattern struct {
+	A Color
+	B Color
+}
+
+func NewRadialGradientPattern(a, b Color) *Pattern {
+	return NewPattern(RadialGradientPattern{a, b})
+}
+
+func (p RadialGradientPattern) String() string {
+	return fmt.Sprintf("RadialGradientPattern( A: %v B: %v )", p.A, p.B)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p RadialGradientPattern) LocalPatternAt(point Tuple) Color {
+	fraction := math.Min(math.Sqrt(point.X*point.X+point.Z*point.Z), 1)
+	return p.A.Add(p.B.Subtract(p.A).Multiply(fraction))
+}
+
+func (p RadialGradientPattern) LocalUVPatternAt(u, v float64) Color {
+	return Colors["Black"]
+}
+
+func (p RadialGradientPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(RadialGradientPattern)
+	return p.A.IsEqualTo(p2Pattern.A) && p.B.IsEqualTo(p2Pattern.B)
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p RadialGradientPattern) localType() string {
+	return "RadialGradientPattern"
+}
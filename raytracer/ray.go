@@ -7,10 +7,37 @@ import (
 type Ray struct {
 	Origin    Tuple
 	Direction Tuple // aka velocity (i.e. how far it moves per time unit)
+
+	// Optionally, how wide the ray is (e.g. the width of a pixel, for rays from a
+	// Camera), for picking the level of detail of textures: it's Footprint wide at
+	// the Origin, and gets Spread wider per unit of distance.
+	Footprint float64
+	Spread    float64
+
+	// Optionally, the pixel's own sequences for jittering random samples, for rays from a
+	// Camera (see PixelJitter).
+	Jitter *PixelJitter
+
+	// Whether the ray is one of the rays that a rough surface spread a reflection or refraction
+	// out over (or was spawned by one), so that it isn't spread out again (see World.GlossySamples).
+	Glossy bool
+
+	// Which color channel the ray carries, once dispersion has split a refraction up
+	// by channel (see Material.Abbe), so that it isn't split up again: AllChannels, or
+	// RedChannel, GreenChannel or BlueChannel.
+	Channel int
 }
 
+// The color channels that a Ray can carry (see Ray.Channel).
+const (
+	AllChannels = iota
+	RedChannel
+	GreenChannel
+	BlueChannel
+)
+
 func NewRay(o, d Tuple) *Ray {
-	return &Ray{o, d}
+	return &Ray{Origin: o, Direction: d}
 }
 
 func (r *Ray) String() string {
@@ -22,10 +49,14 @@ func (r *Ray) Position(time float64) Tuple {
 }
 
 func (r *Ray) Transform(t Matrix) *Ray {
-	return NewRay(
+	transformed := NewRay(
 		t.MultiplyByTuple(r.Origin),
 		t.MultiplyByTuple(r.Direction),
 	)
+	transformed.Footprint, transformed.Spread = r.Footprint, r.Spread
+	transformed.Jitter, transformed.Glossy = r.Jitter, r.Glossy
+	transformed.Channel = r.Channel
+	return transformed
 }
 
 func (r *Ray) IsEqualTo(r2 *Ray) bool {
@@ -58,3 +58,22 @@ func maxFloat64(floats ...float64) float64 {
 func altMod(x, y float64) float64 {
 	return x - y*math.Floor(x/y)
 }
+
+// Same as altMod, but for ints.
+func altModInt(x, y int) int {
+	return ((x % y) + y) % y
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
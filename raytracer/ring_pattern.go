@@ -6,12 +6,20 @@ import (
 )
 
 type RingPattern struct {
-	A Color
-	B Color
+	A        Color
+	B        Color
+	PatternA *Pattern // if set, used instead of A
+	PatternB *Pattern // if set, used instead of B
 }
 
 func NewRingPattern(a, b Color) *Pattern {
-	return NewPattern(RingPattern{a, b})
+	return NewPattern(RingPattern{A: a, B: b})
+}
+
+// Returns a ring pattern of two other patterns, which each keep their own
+// transformation (relative to this pattern's).
+func NewRingPatternFromPatterns(a, b *Pattern) *Pattern {
+	return NewPattern(RingPattern{PatternA: a, PatternB: b})
 }
 
 func (p RingPattern) String() string {
@@ -26,9 +34,9 @@ func (p RingPattern) LocalPatternAt(point Tuple) Color {
 	xSquared := math.Pow(point.X, 2)
 	zSquared := math.Pow(point.Z, 2)
 	if int(math.Floor(math.Sqrt(xSquared+zSquared)))%2 == 0 {
-		return p.A
+		return colorOrPatternAt(p.A, p.PatternA, point)
 	} else {
-		return p.B
+		return colorOrPatternAt(p.B, p.PatternB, point)
 	}
 }
 
@@ -37,9 +45,11 @@ func (p RingPattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (rp RingPattern) localIsEqualTo(rp2 PatternInterface) bool {
-	rp2Pattern := rp2.(*RingPattern)
+	rp2Pattern := rp2.(RingPattern)
 	if !rp.A.IsEqualTo(rp2Pattern.A) || !rp.B.IsEqualTo(rp2Pattern.B) {
 		return false
+	} else if !patternsAreEqual(rp.PatternA, rp2Pattern.PatternA) || !patternsAreEqual(rp.PatternB, rp2Pattern.PatternB) {
+		return false
 	}
 	return true
 }
@@ -2,6 +2,7 @@ package raytracer
 
 import (
 	"fmt"
+	"math/rand"
 	"sync"
 )
 
@@ -17,6 +18,17 @@ func NewSequence(s ...float64) Sequence {
 	return Sequence{s, 0, &sync.Mutex{}}
 }
 
+// Returns a deterministic sequence of n pseudo-random numbers in [0, 1), generated from seed.
+// Useful when a renderer needs many samples (e.g. jitter) but the image should be reproducible.
+func NewRandomSequence(seed int64, n int) Sequence {
+	r := rand.New(rand.NewSource(seed))
+	numbers := make([]float64, n)
+	for i := range numbers {
+		numbers[i] = r.Float64()
+	}
+	return NewSequence(numbers...)
+}
+
 func (s Sequence) String() string {
 	return fmt.Sprintf(
 		"Shape(\n  Numbers: %v\n  currentIndex: %v\n)",
@@ -42,3 +54,41 @@ func (s *Sequence) Next() float64 {
 	s.mutex.Unlock()
 	return n
 }
+
+// Returns a copy of the sequence that starts at a different place for each n, e.g. so
+// that each pixel of an image can have its own numbers (see PixelJitter).
+func (s *Sequence) Fork(n int) *Sequence {
+	fork := NewSequence(s.Numbers...)
+	if len(s.Numbers) > 0 {
+		// NB a multiplicative hash, so that neighboring pixels start far apart.
+		fork.currentIndex = int((uint64(n) * 2654435761) % uint64(len(s.Numbers)))
+	}
+	return &fork
+}
+
+// PixelJitter gives the rays of one pixel (and the rays they spawn) their own copies
+// of the sequences that random samples are jittered with, e.g. World.GlossyJitter.
+// Otherwise every pixel would share them, and which pixel got which numbers would depend
+// on how the pixels were split between goroutines. Each pixel is rendered by one
+// goroutine, so it doesn't need a mutex.
+type PixelJitter struct {
+	Pixel     int
+	sequences map[*Sequence]*Sequence
+}
+
+func NewPixelJitter(pixel int) *PixelJitter {
+	return &PixelJitter{Pixel: pixel, sequences: map[*Sequence]*Sequence{}}
+}
+
+// Returns the pixel's own copy of s (see Sequence.Fork), or s itself without a PixelJitter.
+func (pj *PixelJitter) Sequence(s *Sequence) *Sequence {
+	if pj == nil || s == nil {
+		return s
+	}
+	fork, found := pj.sequences[s]
+	if !found {
+		fork = s.Fork(pj.Pixel)
+		pj.sequences[s] = fork
+	}
+	return fork
+}
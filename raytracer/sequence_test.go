@@ -11,3 +11,42 @@ func TestANumberGeneratorReturnsACyclicSequenceOfNumbers(t *testing.T) {
 	assertEqualFloat64(t, 1.0, gen.Next())
 	assertEqualFloat64(t, 0.1, gen.Next())
 }
+
+func TestARandomSequenceIsDeterministicForASeed(t *testing.T) {
+	gen1 := NewRandomSequence(42, 10)
+	gen2 := NewRandomSequence(42, 10)
+	gen3 := NewRandomSequence(43, 10)
+
+	assert(t, gen1.IsEqualTo(gen2))
+	assert(t, !gen1.IsEqualTo(gen3))
+	for i := 0; i < 10; i++ {
+		n := gen1.Next()
+		assert(t, n >= 0 && n < 1)
+	}
+}
+
+func TestForkingASequenceStartsItSomewhereElse(t *testing.T) {
+	gen := NewRandomSequence(42, 10)
+	fork1 := gen.Fork(1)
+	fork2 := gen.Fork(1)
+	fork3 := gen.Fork(2)
+
+	assert(t, fork1.IsEqualTo(gen))
+	n1, n2, n3 := fork1.Next(), fork2.Next(), fork3.Next()
+	assertEqualFloat64(t, n1, n2)
+	assert(t, n1 != n3)
+	assertEqualFloat64(t, gen.Numbers[0], gen.Next()) // the original is untouched
+}
+
+func TestAPixelJitterHasItsOwnCopyOfEachSequence(t *testing.T) {
+	gen1 := NewRandomSequence(42, 10)
+	gen2 := NewRandomSequence(43, 10)
+	pj := NewPixelJitter(3)
+
+	assert(t, pj.Sequence(&gen1) == pj.Sequence(&gen1))
+	assert(t, pj.Sequence(&gen1) != &gen1)
+	assert(t, pj.Sequence(&gen1) != pj.Sequence(&gen2))
+
+	var noJitter *PixelJitter
+	assert(t, noJitter.Sequence(&gen1) == &gen1)
+}
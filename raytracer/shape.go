@@ -13,6 +13,13 @@ type ShapeInterface interface {
 	localString() string
 }
 
+// Shapes that have their own texture coordinates (e.g. a Triangle's per-vertex UVs) can
+// implement this in addition to ShapeInterface, so that textures follow the shape's
+// geometry (see NewShapeTextureMapPattern). Both u and v are usually 0..1.
+type UVShapeInterface interface {
+	LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64)
+}
+
 // Shape is a general shape (Transform+Material), with the specific type of shape stored as a ShapeInterface in LocalShape.
 // LocaleShape-specific functions are prefixed with "local", e.g. localFoo().
 type Shape struct {
@@ -32,7 +39,9 @@ func NewShape(si ShapeInterface) *Shape {
 		Material:   DefaultMaterial(),
 		Shadows:    true, // does this shape cast shadows?
 	}
-	s.SetTransform(IdentityMatrix())
+	// NB same as SetTransform(IdentityMatrix()), without inverting it, which adds up for meshes with millions of triangles.
+	s.Transform = IdentityMatrix()
+	s.InverseTransform = IdentityMatrix()
 	return &s
 }
 
@@ -60,7 +69,18 @@ func (s *Shape) Intersect(r *Ray) Intersections {
 	// Instead of applying object's transformation to object, we can just apply
 	// the inverse of the transformation to the ray.
 	r = r.Transform(s.InverseTransform)
-	return s.LocalShape.LocalIntersect(r, s)
+	xs := s.LocalShape.LocalIntersect(r, s)
+
+	// The shape's own texture coordinates are found in its space too, so the ray's already there.
+	if uvShape, ok := s.LocalShape.(UVShapeInterface); ok {
+		for _, i := range xs {
+			if i.Object == s {
+				i.TexU, i.TexV = uvShape.LocalUVAt(r.Position(i.Time), i)
+				i.HasUV = true
+			}
+		}
+	}
+	return xs
 }
 
 func (s *Shape) NormalAt(worldPoint Tuple, i *Intersection) Tuple {
@@ -69,6 +89,17 @@ func (s *Shape) NormalAt(worldPoint Tuple, i *Intersection) Tuple {
 	return s.NormalToWorld(objectNormal)
 }
 
+// Returns the shape's own texture coordinates at a point on it, and whether it has
+// any (see UVShapeInterface). The hit may be nil.
+func (s *Shape) UVAt(worldPoint Tuple, hit *Intersection) (float64, float64, bool) {
+	uvShape, ok := s.LocalShape.(UVShapeInterface)
+	if !ok {
+		return 0, 0, false
+	}
+	u, v := uvShape.LocalUVAt(s.WorldToObject(worldPoint), hit)
+	return u, v, true
+}
+
 // Transforms a point in world space to object space, accounting for the chain of parents in between.
 func (s *Shape) WorldToObject(worldPoint Tuple) Tuple {
 	if s.Parent != nil {
@@ -142,7 +173,7 @@ func (s *Shape) AddChildren(shapes ...*Shape) {
 	// HACK: sucks to have to create new Group. When we test out replacing value LocalShape with *LocalShape, we can just alter the Group directly? Otherwise, generalize this into a Copy() method for at least Group.
 	group := s.LocalShape.(Group)
 	group.Children = append(group.Children, shapes...)
-	for _, shape := range group.Children {
+	for _, shape := range shapes {
 		shape.Parent = s
 	}
 	s.LocalShape = group
@@ -13,12 +13,20 @@ type SmoothTriangle struct {
 	E1        Tuple
 	E2        Tuple
 	Normal    Tuple
+	UV1       Tuple // texture coordinates of each point (X=u, Y=v)
+	UV2       Tuple
+	UV3       Tuple
 	boundsMin Tuple
 	boundsMax Tuple
 }
 
 func NewSmoothTriangle(p1, p2, p3, n1, n2, n3 Tuple) *Shape {
-	tri := SmoothTriangle{P1: p1, P2: p2, P3: p3, N1: n1, N2: n2, N3: n3}
+	return NewSmoothTriangleWithUVs(p1, p2, p3, n1, n2, n3, NewPoint(0, 0, 0), NewPoint(1, 0, 0), NewPoint(0, 1, 0))
+}
+
+// Same as NewTriangleWithUVs, but for a SmoothTriangle.
+func NewSmoothTriangleWithUVs(p1, p2, p3, n1, n2, n3, uv1, uv2, uv3 Tuple) *Shape {
+	tri := SmoothTriangle{P1: p1, P2: p2, P3: p3, N1: n1, N2: n2, N3: n3, UV1: uv1, UV2: uv2, UV3: uv3}
 	// Pre-calculate edge vectors and normal
 	tri.E1 = p2.Subtract(p1)
 	tri.E2 = p3.Subtract(p1)
@@ -79,6 +87,11 @@ func (t SmoothTriangle) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple
 		Add(t.N1.Multiply(1 - hit.U - hit.V))
 }
 
+// Fulfills UVShapeInterface, by interpolating the UVs of the points.
+func (t SmoothTriangle) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	return interpolateUVs(t.P1, t.E1, t.E2, t.UV1, t.UV2, t.UV3, localPoint, hit)
+}
+
 func (t SmoothTriangle) localIsEqualTo(t2 ShapeInterface) bool {
 	t2SmoothTriangle := t2.(*SmoothTriangle)
 	if !t.P1.IsEqualTo(t2SmoothTriangle.P1) {
@@ -95,13 +108,15 @@ func (t SmoothTriangle) localIsEqualTo(t2 ShapeInterface) bool {
 		return false
 	} else if !t.N3.IsEqualTo(t2SmoothTriangle.N3) {
 		return false
+	} else if !t.UV1.IsEqualTo(t2SmoothTriangle.UV1) || !t.UV2.IsEqualTo(t2SmoothTriangle.UV2) || !t.UV3.IsEqualTo(t2SmoothTriangle.UV3) {
+		return false
 	}
 	return true
 }
 
 func (t SmoothTriangle) String() string {
 	return fmt.Sprintf(
-		"SmoothTriangle( P1: %v P2: %v P3: %v N1: %v N2: %v N3: %v E1: %v E2: %v Norma: %v boundsMin: %v boundsMax: %v )",
+		"SmoothTriangle( P1: %v P2: %v P3: %v N1: %v N2: %v N3: %v E1: %v E2: %v Norma: %v UV1: %v UV2: %v UV3: %v boundsMin: %v boundsMax: %v )",
 		t.P1,
 		t.P2,
 		t.P3,
@@ -111,6 +126,9 @@ func (t SmoothTriangle) String() string {
 		t.E1,
 		t.E2,
 		t.Normal,
+		t.UV1,
+		t.UV2,
+		t.UV3,
 		t.boundsMin,
 		t.boundsMax,
 	)
@@ -0,0 +1,41 @@
+package raytracer
+
+import (
+	"fmt"
+)
+
+// SolidPattern is the same color everywhere.
+type SolidPattern struct {
+	Color Color
+}
+
+func NewSolidPattern(c Color) *Pattern {
+	return NewPattern(SolidPattern{c})
+}
+
+func (p SolidPattern) String() string {
+	return fmt.Sprintf("SolidPattern( Color: %v )", p.Color)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p SolidPattern) LocalPatternAt(point Tuple) Color {
+	return p.Color
+}
+
+func (p SolidPattern) LocalUVPatternAt(u, v float64) Color {
+	return p.Color
+}
+
+func (p SolidPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(SolidPattern)
+	return p.Color.IsEqualTo(p2Pattern.Color)
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p SolidPattern) localType() string {
+	return "SolidPattern"
+}
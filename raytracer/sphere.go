@@ -64,6 +64,11 @@ func (s Sphere) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
 	return localPoint.Subtract(s.Origin)
 }
 
+// Fulfills UVShapeInterface, with a SphericalMap around the sphere's origin.
+func (s Sphere) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	return SphericalMap(localPoint.Subtract(s.Origin))
+}
+
 func (s Sphere) localIsEqualTo(s2 ShapeInterface) bool {
 	// NB I still don't know why we need to do a type assertiong to *Sphere
 	// instead of Sphere, but it fixes this panic when comparing two
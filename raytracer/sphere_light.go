@@ -0,0 +1,90 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// SphereLight is a round bulb: a spherical light source that is sampled
+// uniformly over the part of its surface that's visible from the lit point.
+type SphereLight struct {
+	Center Tuple
+	Radius float64
+}
+
+// Returns a spherical light source, sampled with usteps*vsteps cells.
+func NewSphereLight(center Tuple, radius float64, usteps, vsteps float64, intensity Color) *AreaLight {
+	jitter := NewSequence(0.5)
+	return &AreaLight{
+		Corner:     center,
+		USteps:     usteps,
+		VSteps:     vsteps,
+		Samples:    usteps * vsteps,
+		Intensity:  intensity,
+		Jitter:     &jitter,
+		LocalLight: SphereLight{center, radius},
+	}
+}
+
+func (sl SphereLight) String() string {
+	return fmt.Sprintf("SphereLight( Center: %v Radius: %v )", sl.Center, sl.Radius)
+}
+
+/////////////////////////
+// LightInterface methods
+/////////////////////////
+
+// Samples the cone of directions from p that hit the sphere, which spreads the
+// samples evenly over the visible cap instead of wasting them on the far side.
+func (sl SphereLight) LocalPointOnLight(su, sv float64, p Tuple) Tuple {
+	toCenter := sl.Center.Subtract(p)
+	distanceSquared := toCenter.Dot(toCenter)
+	radiusSquared := sl.Radius * sl.Radius
+	phi := 2 * math.Pi * sv
+
+	// If p is inside the sphere, the whole surface is visible.
+	if distanceSquared <= radiusSquared {
+		z := 1 - 2*su
+		r := math.Sqrt(math.Max(0, 1-z*z))
+		return sl.Center.Add(NewVector(r*math.Cos(phi), r*math.Sin(phi), z).Multiply(sl.Radius))
+	}
+
+	w := toCenter.Normalized()
+	u, v := w.OrthonormalBasis()
+	cosThetaMax := math.Sqrt(math.Max(0, 1-radiusSquared/distanceSquared))
+	cosTheta := 1 - su*(1-cosThetaMax)
+	sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+	direction := u.Multiply(math.Cos(phi) * sinTheta).
+		Add(v.Multiply(math.Sin(phi) * sinTheta)).
+		Add(w.Multiply(cosTheta))
+
+	// Find where the sampled direction first hits the sphere.
+	sphereToPoint := p.Subtract(sl.Center)
+	b := direction.Dot(sphereToPoint)
+	c := sphereToPoint.Dot(sphereToPoint) - radiusSquared
+	discriminant := math.Max(0, b*b-c) // clamp directions that graze the edge
+	t := -b - math.Sqrt(discriminant)
+
+	return p.Add(direction.Multiply(t))
+}
+
+// A sphere shines in every direction.
+func (sl SphereLight) localIntensityAt(p Tuple) float64 {
+	return 1.0
+}
+
+func (sl SphereLight) localIsEqualTo(l2 LightInterface) bool {
+	sl2 := l2.(SphereLight)
+	if !sl.Center.IsEqualTo(sl2.Center) {
+		return false
+	} else if sl.Radius != sl2.Radius {
+		return false
+	}
+	return true
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (sl SphereLight) localType() string {
+	return "SphereLight"
+}
@@ -0,0 +1,74 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// SpotLight is a point light that only shines within a cone around its Direction: fully
+// within InnerConeAngle, and fading out toward OuterConeAngle (both in radians, from the
+// Direction to the edge of the cone).
+type SpotLight struct {
+	Position       Tuple
+	Direction      Tuple
+	InnerConeAngle float64
+	OuterConeAngle float64
+}
+
+// Returns a spot light at position, shining toward direction.
+func NewSpotLight(position, direction Tuple, innerConeAngle, outerConeAngle float64, intensity Color) *AreaLight {
+	jitter := NewSequence(0.0) // like PointLights, it's a single point of light
+	return &AreaLight{
+		Corner:     position,
+		USteps:     1,
+		VSteps:     1,
+		Samples:    1,
+		Intensity:  intensity,
+		Jitter:     &jitter,
+		LocalLight: SpotLight{position, direction.Normalized(), innerConeAngle, outerConeAngle},
+	}
+}
+
+func (sl SpotLight) String() string {
+	return fmt.Sprintf("SpotLight( Position: %v Direction: %v InnerConeAngle: %v OuterConeAngle: %v )", sl.Position, sl.Direction, sl.InnerConeAngle, sl.OuterConeAngle)
+}
+
+/////////////////////////
+// LightInterface methods
+/////////////////////////
+
+func (sl SpotLight) LocalPointOnLight(su, sv float64, p Tuple) Tuple {
+	return sl.Position
+}
+
+// Fades out between the inner and outer cones, with the square of how far the cosine of
+// the angle to p is between theirs (like glTF's KHR_lights_punctual spot lights).
+func (sl SpotLight) localIntensityAt(p Tuple) float64 {
+	toPoint := p.Subtract(sl.Position)
+	if toPoint.Magnitude() < EPSILON {
+		return 0.0
+	}
+	cosAngle := toPoint.Normalized().Dot(sl.Direction)
+	cosOuter, cosInner := math.Cos(sl.OuterConeAngle), math.Cos(sl.InnerConeAngle)
+	t := (cosAngle - cosOuter) / math.Max(cosInner-cosOuter, 0.001)
+	t = math.Min(1, math.Max(0, t))
+	return t * t
+}
+
+func (sl SpotLight) localIsEqualTo(l2 LightInterface) bool {
+	sl2 := l2.(SpotLight)
+	if !sl.Position.IsEqualTo(sl2.Position) {
+		return false
+	} else if !sl.Direction.IsEqualTo(sl2.Direction) {
+		return false
+	} else if sl.InnerConeAngle != sl2.InnerConeAngle {
+		return false
+	} else if sl.OuterConeAngle != sl2.OuterConeAngle {
+		return false
+	}
+	return true
+}
+
+func (sl SpotLight) localType() string {
+	return "SpotLight"
+}
@@ -0,0 +1,125 @@
+package raytracer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Parses an STL file (ASCII or binary) into the same structure as an OBJ file: its facets
+// are triangles in DefaultGroup. Facets in STL files don't share vertices, so points that
+// are exactly the same are merged into one vertex, which lets options.SmoothNormals (see
+// ObjOptions) smooth across them. Facets with fewer than 3 distinct points are skipped.
+func ParseStl(r io.Reader, options ObjOptions) (*ObjFile, error) {
+	br := bufio.NewReader(r)
+	of := newObjFile(options)
+	vertexIndices := map[Tuple]int{}
+	addFacet := func(points [3]Tuple) {
+		indices := make([]int, 3)
+		for idx, p := range points {
+			vi, found := vertexIndices[p]
+			if !found {
+				of.Vertices = append(of.Vertices, p)
+				vi = len(of.Vertices) - 1
+				vertexIndices[p] = vi
+			}
+			indices[idx] = vi
+		}
+		if indices[0] == indices[1] || indices[1] == indices[2] || indices[0] == indices[2] {
+			return
+		}
+		of.addFace(indices, nil, nil)
+	}
+
+	var err error
+	if isAsciiStl(br) {
+		err = parseAsciiStl(br, addFacet)
+	} else {
+		err = parseBinaryStl(br, addFacet)
+	}
+	if err != nil {
+		return of, err
+	}
+
+	of.finish()
+	return of, nil
+}
+
+// Does the STL file look like ASCII? Binary files can start with "solid" too (it's
+// in their header), so it checks that a facet follows it.
+func isAsciiStl(br *bufio.Reader) bool {
+	start, _ := br.Peek(512)
+	return bytes.HasPrefix(bytes.TrimSpace(start), []byte("solid")) && bytes.Contains(start, []byte("facet"))
+}
+
+func parseAsciiStl(r io.Reader, addFacet func([3]Tuple)) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	points := [3]Tuple{}
+	pointCount := 0
+	for scanner.Scan() {
+		lineNumber += 1
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "facet":
+			pointCount = 0
+		case "vertex":
+			if len(fields) != 4 || pointCount == 3 {
+				return fmt.Errorf("STL line %d: invalid vertex\n", lineNumber)
+			}
+			coords := [3]float64{}
+			for idx := range coords {
+				f, err := strconv.ParseFloat(fields[idx+1], 64)
+				if err != nil {
+					return fmt.Errorf("STL line %d: cannot convert %s to float64\n", lineNumber, fields[idx+1])
+				}
+				coords[idx] = f
+			}
+			points[pointCount] = NewPoint(coords[0], coords[1], coords[2])
+			pointCount += 1
+		case "endfacet":
+			if pointCount != 3 {
+				return fmt.Errorf("STL line %d: facet needs 3 vertices, but has %d\n", lineNumber, pointCount)
+			}
+			addFacet(points)
+		}
+		// Anything else (e.g. solid, outer loop or the facet's normal, which is
+		// recalculated from its points) is ignored.
+	}
+	return scanner.Err()
+}
+
+func parseBinaryStl(r io.Reader, addFacet func([3]Tuple)) error {
+	header := [80]byte{}
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return fmt.Errorf("STL file is too short for its header\n")
+	}
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return fmt.Errorf("STL file is too short for its header\n")
+	}
+
+	facet := make([]byte, 50) // a normal and 3 points (12 float32s) and an "attribute byte count" (uint16)
+	for idx := 0; idx < int(count); idx++ {
+		if _, err := io.ReadFull(r, facet); err != nil {
+			return fmt.Errorf("STL file has %d facets, but says it has %d\n", idx, count)
+		}
+		coord := func(i int) float64 {
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(facet[i*4:])))
+		}
+		points := [3]Tuple{}
+		for p := range points {
+			points[p] = NewPoint(coord(3+p*3), coord(4+p*3), coord(5+p*3))
+		}
+		addFacet(points)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package raytracer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParsingStlFiles(t *testing.T) {
+	triangles := Tessellate(NewCube(), DefaultTessellationSegments)
+	var ascii, binary bytes.Buffer
+	if err := WriteAsciiStl(&ascii, "cube", triangles); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteBinaryStl(&binary, triangles); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range []*bytes.Buffer{&ascii, &binary} {
+		parser, err := ParseStl(file, ObjOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The facets' points are merged into the cube's 8 corners.
+		assertEqualInt(t, 8+1, len(parser.Vertices))
+		children := parser.DefaultGroup.LocalShape.(Group).Children
+		assertEqualInt(t, 12, len(children))
+		for idx, child := range children {
+			assertEqualTuple(t, triangles[idx].Normal(), child.LocalShape.(*Triangle).Normal)
+		}
+	}
+}
+
+func TestParsingAnStlFileWithSmoothNormals(t *testing.T) {
+	triangles := Tessellate(NewSphere(), 16)
+	var file bytes.Buffer
+	if err := WriteBinaryStl(&file, triangles); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := ParseStl(&file, ObjOptions{SmoothNormals: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, child := range parser.DefaultGroup.LocalShape.(Group).Children {
+		tri := child.LocalShape.(*SmoothTriangle)
+		// Each point's normal is close to the sphere's at that point.
+		if tri.N1.Dot(NewVector(tri.P1.X, tri.P1.Y, tri.P1.Z)) < 0.99 {
+			t.Errorf("Expected %v to be close to the sphere's normal at %v", tri.N1, tri.P1)
+		}
+	}
+}
+
+func TestParsingATruncatedStlFile(t *testing.T) {
+	var file bytes.Buffer
+	if err := WriteBinaryStl(&file, Tessellate(NewCube(), DefaultTessellationSegments)); err != nil {
+		t.Fatal(err)
+	}
+	file.Truncate(file.Len() - 10)
+
+	if _, err := ParseStl(&file, ObjOptions{}); err == nil {
+		t.Errorf("Expected an error parsing a truncated STL file")
+	}
+}
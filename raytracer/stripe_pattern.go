@@ -6,12 +6,20 @@ import (
 )
 
 type StripePattern struct {
-	A Color
-	B Color
+	A        Color
+	B        Color
+	PatternA *Pattern // if set, used instead of A
+	PatternB *Pattern // if set, used instead of B
 }
 
 func NewStripePattern(a, b Color) *Pattern {
-	return NewPattern(StripePattern{a, b})
+	return NewPattern(StripePattern{A: a, B: b})
+}
+
+// Returns a stripe pattern of two other patterns, which each keep their own
+// transformation (relative to this pattern's).
+func NewStripePatternFromPatterns(a, b *Pattern) *Pattern {
+	return NewPattern(StripePattern{PatternA: a, PatternB: b})
 }
 
 func (s StripePattern) String() string {
@@ -24,9 +32,9 @@ func (s StripePattern) String() string {
 
 func (s StripePattern) LocalPatternAt(point Tuple) Color {
 	if math.Mod(math.Floor(point.X), 2) == 0 {
-		return s.A
+		return colorOrPatternAt(s.A, s.PatternA, point)
 	} else {
-		return s.B
+		return colorOrPatternAt(s.B, s.PatternB, point)
 	}
 }
 
@@ -35,9 +43,11 @@ func (sp StripePattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (sp StripePattern) localIsEqualTo(sp2 PatternInterface) bool {
-	sp2StripePattern := sp2.(*StripePattern)
+	sp2StripePattern := sp2.(StripePattern)
 	if !sp.A.IsEqualTo(sp2StripePattern.A) || !sp.B.IsEqualTo(sp2StripePattern.B) {
 		return false
+	} else if !patternsAreEqual(sp.PatternA, sp2StripePattern.PatternA) || !patternsAreEqual(sp.PatternB, sp2StripePattern.PatternB) {
+		return false
 	}
 	return true
 }
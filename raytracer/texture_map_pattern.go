@@ -7,13 +7,55 @@ import (
 
 type TextureMapPattern struct {
 	Pattern *Pattern
-	UVMap   func(Tuple) (float64, float64)
+	UVMap   func(Tuple) (float64, float64) // if nil, the shape's own UVs are used (see NewShapeTextureMapPattern)
 }
 
 func NewTextureMapPattern(p *Pattern, f func(Tuple) (float64, float64)) *Pattern {
 	return NewPattern(TextureMapPattern{p, f})
 }
 
+// Returns a TextureMapPattern that uses the texture coordinates of the shape it's on
+// (see UVShapeInterface), e.g. a Triangle's per-vertex UVs or a Cylinder's caps, instead
+// of a UVMap. The pattern's transformation doesn't apply to them. For shapes without
+// their own UVs (or without a shape, e.g. PatternAt), it falls back to PlanarMap.
+func NewShapeTextureMapPattern(p *Pattern) *Pattern {
+	return NewPattern(TextureMapPattern{Pattern: p})
+}
+
+// Returns the uv of a point with the UVMap, or PlanarMap if there isn't one.
+func (p TextureMapPattern) uvAt(point Tuple) (float64, float64) {
+	if p.UVMap == nil {
+		return PlanarMap(point)
+	}
+	return p.UVMap(point)
+}
+
+// Returns the color at the shape's own u and v at worldPoint (see NewShapeTextureMapPattern),
+// for an area around it that's footprint wide.
+func (p TextureMapPattern) shapeUVPatternAt(s *Shape, worldPoint Tuple, u, v, footprint float64) Color {
+	fp, ok := p.Pattern.LocalPattern.(UVFootprintPatternInterface)
+	if !ok || footprint <= 0 {
+		return p.Pattern.UVPatternAt(u, v)
+	}
+
+	uvAt := func(point Tuple) (float64, float64) {
+		u, v, _ := s.UVAt(point, nil)
+		return u, v
+	}
+	return fp.LocalUVPatternAtFootprint(u, v, uvFootprint(uvAt, worldPoint, u, v, footprint))
+}
+
+// Returns how wide an area around point that's footprint wide is in u and v (i.e. how far
+// they change across it), the widest of each axis.
+func uvFootprint(uvAt func(Tuple) (float64, float64), point Tuple, u, v, footprint float64) float64 {
+	widest := 0.0
+	for _, axis := range []Tuple{NewVector(1, 0, 0), NewVector(0, 1, 0), NewVector(0, 0, 1)} {
+		u2, v2 := uvAt(point.Add(axis.Multiply(footprint)))
+		widest = maxFloat64(widest, math.Abs(wrapUVDelta(u2-u)), math.Abs(wrapUVDelta(v2-v)))
+	}
+	return widest
+}
+
 func (p TextureMapPattern) String() string {
 	return fmt.Sprintf("TextureMapPattern(\n  %v\n  %T\n)", p.Pattern, p.UVMap)
 }
@@ -78,16 +120,27 @@ func CylindricalMap(p Tuple) (float64, float64) {
 
 func (p TextureMapPattern) LocalPatternAt(point Tuple) Color {
 	// TODO: is this right?
-	u, v := p.UVMap(point)
+	u, v := p.uvAt(point)
 	return p.Pattern.UVPatternAt(u, v)
 }
 
+// Fulfills FootprintPatternInterface, by finding how wide the footprint is in u and v
+// (i.e. how far they change across it), for UV patterns that fulfill UVFootprintPatternInterface.
+func (p TextureMapPattern) LocalPatternAtFootprint(point Tuple, footprint float64) Color {
+	u, v := p.uvAt(point)
+	fp, ok := p.Pattern.LocalPattern.(UVFootprintPatternInterface)
+	if !ok {
+		return p.Pattern.UVPatternAt(u, v)
+	}
+	return fp.LocalUVPatternAtFootprint(u, v, uvFootprint(p.uvAt, point, u, v, footprint))
+}
+
 func (p TextureMapPattern) LocalUVPatternAt(u, v float64) Color {
 	return p.Pattern.UVPatternAt(u, v)
 }
 
 func (p TextureMapPattern) localIsEqualTo(p2 PatternInterface) bool {
-	p2Pattern := p2.(*TextureMapPattern)
+	p2Pattern := p2.(TextureMapPattern)
 	if !p.Pattern.IsEqualTo(p2Pattern.Pattern) {
 		return false
 		// TODO: add IsEqualTo to UVMap
@@ -94,3 +94,17 @@ func NewRotateZ(radians float64) Matrix {
 	})
 	return scale
 }
+
+// Returns a rotation by the quaternion x, y, z, w (e.g. from a glTF file), which
+// doesn't need to be normalized.
+func NewRotateQuaternion(x, y, z, w float64) Matrix {
+	length := math.Sqrt(x*x + y*y + z*z + w*w)
+	x, y, z, w = x/length, y/length, z/length, w/length
+	rotation := NewMatrix(4, 4, []float64{
+		1 - 2*(y*y+z*z), 2 * (x*y - z*w), 2 * (x*z + y*w), 0,
+		2 * (x*y + z*w), 1 - 2*(x*x+z*z), 2 * (y*z - x*w), 0,
+		2 * (x*z - y*w), 2 * (y*z + x*w), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	})
+	return rotation
+}
@@ -138,6 +138,17 @@ func TestRotatingPointAroundZAxis(t *testing.T) {
 	assertEqualTuple(t, NewPoint(-1, 0, 0), full_quarter_rotation.MultiplyByTuple(point))
 }
 
+func TestRotatingPointByQuaternion(t *testing.T) {
+	point := NewPoint(0, 1, 0)
+	half := math.Sin(math.Pi / 8) // a half quarter rotation around z is sin(θ/2) along z
+	half_quarter_rotation := NewRotateQuaternion(0, 0, half, math.Cos(math.Pi/8))
+	full_quarter_rotation := NewRotateQuaternion(0, 0, 1, 1)
+
+	assertEqualTuple(t, NewPoint(-math.Sqrt(2)/2, math.Sqrt(2)/2, 0), half_quarter_rotation.MultiplyByTuple(point))
+	assertEqualTuple(t, NewPoint(-1, 0, 0), full_quarter_rotation.MultiplyByTuple(point))
+	assertEqualTuple(t, NewRotateX(0.3).MultiplyByTuple(point), NewRotateQuaternion(math.Sin(0.15), 0, 0, math.Cos(0.15)).MultiplyByTuple(point))
+}
+
 // TODO table-based tests for these 6 shearing examples
 func TestShearingPointXByProportionToY(t *testing.T) {
 	point := NewPoint(2, 3, 4)
@@ -9,12 +9,21 @@ type Triangle struct {
 	E1        Tuple
 	E2        Tuple
 	Normal    Tuple
+	UV1       Tuple // texture coordinates of each point (X=u, Y=v)
+	UV2       Tuple
+	UV3       Tuple
 	boundsMin Tuple
 	boundsMax Tuple
 }
 
 func NewTriangle(p1, p2, p3 Tuple) *Shape {
-	tri := Triangle{P1: p1, P2: p2, P3: p3}
+	return NewTriangleWithUVs(p1, p2, p3, NewPoint(0, 0, 0), NewPoint(1, 0, 0), NewPoint(0, 1, 0))
+}
+
+// Same as NewTriangle, but with texture coordinates for each point (e.g. from an OBJ file's
+// "vt" lines). Without them, a triangle's UVs are its barycentric coordinates.
+func NewTriangleWithUVs(p1, p2, p3, uv1, uv2, uv3 Tuple) *Shape {
+	tri := Triangle{P1: p1, P2: p2, P3: p3, UV1: uv1, UV2: uv2, UV3: uv3}
 	// Pre-calculate edge vectors and normal
 	tri.E1 = p2.Subtract(p1)
 	tri.E2 = p3.Subtract(p1)
@@ -64,7 +73,7 @@ func (t Triangle) LocalIntersect(r *Ray, shape *Shape) Intersections {
 		}
 
 		time := f * t.E2.Dot(originCrossE1)
-		return Intersections{NewIntersection(time, shape)}
+		return Intersections{NewIntersectionWithUV(time, shape, u, v)}
 	}
 }
 
@@ -72,6 +81,30 @@ func (t Triangle) LocalNormalAt(localPoint Tuple, hit *Intersection) Tuple {
 	return t.Normal
 }
 
+// Fulfills UVShapeInterface, by interpolating the UVs of the points.
+func (t Triangle) LocalUVAt(localPoint Tuple, hit *Intersection) (float64, float64) {
+	return interpolateUVs(t.P1, t.E1, t.E2, t.UV1, t.UV2, t.UV3, localPoint, hit)
+}
+
+// Interpolates the texture coordinates of a triangle's points at a point on it, using
+// the hit's U and V if there is one, otherwise working them out from the point.
+func interpolateUVs(p1, e1, e2, uv1, uv2, uv3, localPoint Tuple, hit *Intersection) (float64, float64) {
+	var u, v float64
+	if hit != nil {
+		u, v = hit.U, hit.V
+	} else {
+		toPoint := localPoint.Subtract(p1)
+		d11, d12, d22 := e1.Dot(e1), e1.Dot(e2), e2.Dot(e2)
+		d1p, d2p := toPoint.Dot(e1), toPoint.Dot(e2)
+		denominator := d11*d22 - d12*d12
+		u = (d22*d1p - d12*d2p) / denominator
+		v = (d11*d2p - d12*d1p) / denominator
+	}
+
+	w := 1 - u - v
+	return uv1.X*w + uv2.X*u + uv3.X*v, uv1.Y*w + uv2.Y*u + uv3.Y*v
+}
+
 func (t Triangle) localIsEqualTo(t2 ShapeInterface) bool {
 	t2Triangle := t2.(*Triangle)
 	if !t.P1.IsEqualTo(t2Triangle.P1) {
@@ -80,19 +113,24 @@ func (t Triangle) localIsEqualTo(t2 ShapeInterface) bool {
 		return false
 	} else if !t.P3.IsEqualTo(t2Triangle.P3) {
 		return false
+	} else if !t.UV1.IsEqualTo(t2Triangle.UV1) || !t.UV2.IsEqualTo(t2Triangle.UV2) || !t.UV3.IsEqualTo(t2Triangle.UV3) {
+		return false
 	}
 	return true
 }
 
 func (t Triangle) String() string {
 	return fmt.Sprintf(
-		"Triangle( P1: %v P2: %v P3: %v E1: %v E2: %v Normal: %v boundsMin: %v boundsMax: %v )",
+		"Triangle( P1: %v P2: %v P3: %v E1: %v E2: %v Normal: %v UV1: %v UV2: %v UV3: %v boundsMin: %v boundsMax: %v )",
 		t.P1,
 		t.P2,
 		t.P3,
 		t.E1,
 		t.E2,
 		t.Normal,
+		t.UV1,
+		t.UV2,
+		t.UV3,
 		t.boundsMin,
 		t.boundsMax,
 	)
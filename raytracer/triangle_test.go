@@ -76,3 +76,21 @@ func TestARayStrikesATriangle(t *testing.T) {
 	assertEqualInt(t, 1, len(xs))
 	assertEqualFloat64(t, 2, xs[0].Time)
 }
+
+func TestATriangleInterpolatesItsUVs(t *testing.T) {
+	shape := NewTriangleWithUVs(
+		NewPoint(0, 1, 0), NewPoint(-1, 0, 0), NewPoint(1, 0, 0),
+		NewPoint(0.5, 1, 0), NewPoint(0, 0, 0), NewPoint(1, 0, 0),
+	)
+	tri := shape.LocalShape.(*Triangle)
+	r := NewRay(NewPoint(-0.25, 0.5, -2), NewVector(0, 0, 1))
+	xs := tri.LocalIntersect(r, shape)
+
+	u, v := tri.LocalUVAt(NewPoint(-0.25, 0.5, 0), xs[0])
+	assertEqualFloat64(t, 0.375, u)
+	assertEqualFloat64(t, 0.5, v)
+
+	u, v = tri.LocalUVAt(NewPoint(-0.25, 0.5, 0), nil)
+	assertEqualFloat64(t, 0.375, u)
+	assertEqualFloat64(t, 0.5, v)
+}
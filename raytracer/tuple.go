@@ -95,3 +95,18 @@ func (t Tuple) Cross(t2 Tuple) Tuple {
 		(t.X*t2.Y)-(t.Y*t2.X),
 	)
 }
+
+// Returns two unit vectors that, together with this (normalized) vector, form
+// an orthonormal basis. Useful for sampling points around a direction.
+func (t Tuple) OrthonormalBasis() (Tuple, Tuple) {
+	n := t.Normalized()
+	var helper Tuple
+	if math.Abs(n.X) > 0.9 {
+		helper = NewVector(0, 1, 0)
+	} else {
+		helper = NewVector(1, 0, 0)
+	}
+	u := helper.Cross(n).Normalized()
+	v := n.Cross(u)
+	return u, v
+}
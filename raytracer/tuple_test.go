@@ -1,6 +1,7 @@
 package raytracer
 
 import (
+	"fmt"
 	"math"
 	"testing"
 )
@@ -191,6 +192,24 @@ func TestReflectVectorAtSlantedSurface(t *testing.T) {
 	assertEqualTuple(t, expected, actual)
 }
 
+func TestOrthonormalBasisOfAVector(t *testing.T) {
+	testCases := []Tuple{
+		NewVector(0, 1, 0),
+		NewVector(1, 0, 0),
+		NewVector(1, 2, 3),
+	}
+	for idx, n := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			u, v := n.OrthonormalBasis()
+			assertEqualFloat64(t, 1, u.Magnitude())
+			assertEqualFloat64(t, 1, v.Magnitude())
+			assertEqualFloat64(t, 0, u.Dot(v))
+			assertEqualFloat64(t, 0, u.Dot(n))
+			assertEqualFloat64(t, 0, v.Dot(n))
+		})
+	}
+}
+
 /////////////
 // Benchmarks
 /////////////
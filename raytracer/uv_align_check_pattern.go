@@ -57,7 +57,7 @@ func (acp UVAlignCheckPattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (acp UVAlignCheckPattern) localIsEqualTo(acp2 PatternInterface) bool {
-	acp2Pattern := acp2.(*UVAlignCheckPattern)
+	acp2Pattern := acp2.(UVAlignCheckPattern)
 	if !acp.main.IsEqualTo(acp2Pattern.main) {
 		return false
 	} else if !acp.ul.IsEqualTo(acp2Pattern.ul) {
@@ -49,7 +49,7 @@ func (p UVCheckerPattern) LocalUVPatternAt(u, v float64) Color {
 }
 
 func (p UVCheckerPattern) localIsEqualTo(p2 PatternInterface) bool {
-	p2Pattern := p2.(*UVCheckerPattern)
+	p2Pattern := p2.(UVCheckerPattern)
 	if !p.A.IsEqualTo(p2Pattern.A) || !p.B.IsEqualTo(p2Pattern.B) {
 		return false
 	}
@@ -5,17 +5,129 @@ import (
 	"math"
 )
 
+// The ways that a UVImagePattern can sample its canvas.
+const (
+	FilterNearest  = "nearest"  // the closest pixel (the default)
+	FilterBilinear = "bilinear" // a blend of the 4 closest pixels
+	FilterBicubic  = "bicubic"  // a smooth (Catmull-Rom) blend of the 16 closest pixels
+)
+
+// The ways that a UVImagePattern handles u and v outside of 0..1.
+const (
+	WrapRepeat = "repeat" // tile the image (the default)
+	WrapClamp  = "clamp"  // repeat the edge pixels
+	WrapMirror = "mirror" // tile the image, flipping every other tile
+)
+
 // This is a pattern for testing purposes.
 type UVImagePattern struct {
 	Canvas Canvas
+	Filter string // FilterNearest (or empty), FilterBilinear or FilterBicubic
+	Wrap   string // WrapRepeat (or empty), WrapClamp or WrapMirror
+
+	mips []Canvas // the canvas halved in size again and again, down to 1x1 (see NewFilteredUVImagePattern)
 }
 
 func NewUVImagePattern(c Canvas) *Pattern {
-	return NewPattern(UVImagePattern{c})
+	return NewPattern(UVImagePattern{Canvas: c})
+}
+
+// Returns a UVImagePattern with the given filter and wrap mode, that's also mipmapped:
+// when it's far away (see FootprintPatternInterface) it's sampled from smaller, averaged
+// copies of the canvas instead, so it's blurred instead of aliased.
+func NewFilteredUVImagePattern(c Canvas, filter, wrap string) *Pattern {
+	mips := []Canvas{c}
+	for level := c; level.Width > 1 || level.Height > 1; {
+		level = halveCanvas(level)
+		mips = append(mips, level)
+	}
+	return NewPattern(UVImagePattern{Canvas: c, Filter: filter, Wrap: wrap, mips: mips})
 }
 
 func (ip UVImagePattern) String() string {
-	return fmt.Sprintf("UVImagePattern(\n  %s\n)", ip.Canvas)
+	return fmt.Sprintf("UVImagePattern(\n  %s\n  Filter: %v\n  Wrap: %v\n)", ip.Canvas, ip.Filter, ip.Wrap)
+}
+
+// Returns a canvas half the width and height (rounded up), where each pixel is the
+// average of the (up to) 4 pixels it covers.
+func halveCanvas(c Canvas) Canvas {
+	halved := NewCanvas((c.Width+1)/2, (c.Height+1)/2)
+	for y := 0; y < halved.Height; y++ {
+		for x := 0; x < halved.Width; x++ {
+			x1, y1 := minInt(2*x+1, c.Width-1), minInt(2*y+1, c.Height-1)
+			sum := c.PixelAt(2*x, 2*y).Add(c.PixelAt(x1, 2*y)).Add(c.PixelAt(2*x, y1)).Add(c.PixelAt(x1, y1))
+			halved.WritePixel(x, y, sum.Divide(4))
+		}
+	}
+	return halved
+}
+
+// Returns the color of pixel x,y in c, wrapping x and y if they're outside of it.
+func (ip UVImagePattern) wrappedPixelAt(c Canvas, x, y int) Color {
+	return c.PixelAt(wrapIndex(x, c.Width, ip.Wrap), wrapIndex(y, c.Height, ip.Wrap))
+}
+
+// Wraps an index into 0..size-1 with one of the wrap modes (e.g. WrapClamp).
+func wrapIndex(idx, size int, wrap string) int {
+	switch wrap {
+	case WrapClamp:
+		return minInt(maxInt(idx, 0), size-1)
+	case WrapMirror:
+		idx = altModInt(idx, 2*size)
+		if idx >= size {
+			return 2*size - 1 - idx
+		}
+		return idx
+	default:
+		return altModInt(idx, size)
+	}
+}
+
+// Samples the canvas c at u,v with the pattern's Filter.
+func (ip UVImagePattern) sample(c Canvas, u, v float64) Color {
+	v = 1 - v
+
+	switch ip.Filter {
+	case FilterBilinear, FilterBicubic:
+		// Pixel centers are at half-pixel offsets.
+		x, y := u*float64(c.Width)-0.5, v*float64(c.Height)-0.5
+		x0, y0 := math.Floor(x), math.Floor(y)
+		tx, ty := x-x0, y-y0
+
+		if ip.Filter == FilterBilinear {
+			row := func(py int) Color {
+				return ip.wrappedPixelAt(c, int(x0), py).Multiply(1 - tx).Add(ip.wrappedPixelAt(c, int(x0)+1, py).Multiply(tx))
+			}
+			return row(int(y0)).Multiply(1 - ty).Add(row(int(y0) + 1).Multiply(ty))
+		}
+
+		wx, wy := catmullRomWeights(tx), catmullRomWeights(ty)
+		sum := Colors["Black"]
+		for j := 0; j < 4; j++ {
+			for i := 0; i < 4; i++ {
+				pixel := ip.wrappedPixelAt(c, int(x0)+i-1, int(y0)+j-1)
+				sum = sum.Add(pixel.Multiply(wx[i] * wy[j]))
+			}
+		}
+		return sum
+	default:
+		x := u * float64(c.Width-1)
+		y := v * float64(c.Height-1)
+
+		return ip.wrappedPixelAt(c, int(math.Round(x)), int(math.Round(y)))
+	}
+}
+
+// The weights of the 4 pixels around a point t (0..1) between the middle two, for
+// Catmull-Rom interpolation.
+func catmullRomWeights(t float64) [4]float64 {
+	t2, t3 := t*t, t*t*t
+	return [4]float64{
+		(-t3 + 2*t2 - t) / 2,
+		(3*t3 - 5*t2 + 2) / 2,
+		(-3*t3 + 4*t2 + t) / 2,
+		(t3 - t2) / 2,
+	}
 }
 
 /////////////////////////
@@ -27,17 +139,33 @@ func (ip UVImagePattern) LocalPatternAt(point Tuple) Color {
 }
 
 func (ip UVImagePattern) LocalUVPatternAt(u, v float64) Color {
-	v = 1 - v
+	return ip.sample(ip.Canvas, u, v)
+}
+
+// Fulfills UVFootprintPatternInterface, by sampling the two mipmap levels whose pixels
+// are closest in size to the footprint, and blending between them.
+func (ip UVImagePattern) LocalUVPatternAtFootprint(u, v, footprint float64) Color {
+	if len(ip.mips) < 2 || footprint <= 0 {
+		return ip.LocalUVPatternAt(u, v)
+	}
 
-	x := u * float64(ip.Canvas.Width-1)
-	y := v * float64(ip.Canvas.Height-1)
+	level := math.Log2(footprint * float64(maxInt(ip.Canvas.Width, ip.Canvas.Height)))
+	if level <= 0 {
+		return ip.LocalUVPatternAt(u, v)
+	} else if level >= float64(len(ip.mips)-1) {
+		return ip.sample(ip.mips[len(ip.mips)-1], u, v)
+	}
 
-	return ip.Canvas.PixelAt(int(math.Round(x)), int(math.Round(y)))
+	lower := math.Floor(level)
+	t := level - lower
+	a := ip.sample(ip.mips[int(lower)], u, v)
+	b := ip.sample(ip.mips[int(lower)+1], u, v)
+	return a.Multiply(1 - t).Add(b.Multiply(t))
 }
 
 func (ip UVImagePattern) localIsEqualTo(ip2 PatternInterface) bool {
-	ip2Pattern := ip2.(*UVImagePattern)
-	return ip.Canvas.IsEqualTo(ip2Pattern.Canvas)
+	ip2Pattern := ip2.(UVImagePattern)
+	return ip.Canvas.IsEqualTo(ip2Pattern.Canvas) && ip.Filter == ip2Pattern.Filter && ip.Wrap == ip2Pattern.Wrap
 }
 
 // Not returning reflect.TypeOf here because I suspect it
@@ -0,0 +1,45 @@
+package raytracer
+
+import "fmt"
+
+// VertexColorPattern blends the colors of a triangle's three points (e.g. from a PLY file's
+// vertex colors). As a UV pattern, u and v are how much of the second and third colors
+// there are, like the default UVs of a Triangle, so it's meant to be used with
+// NewShapeTextureMapPattern.
+type VertexColorPattern struct {
+	C1 Color
+	C2 Color
+	C3 Color
+}
+
+func NewVertexColorPattern(c1, c2, c3 Color) *Pattern {
+	return NewPattern(VertexColorPattern{c1, c2, c3})
+}
+
+func (p VertexColorPattern) String() string {
+	return fmt.Sprintf("VertexColorPattern( C1: %v C2: %v C3: %v )", p.C1, p.C2, p.C3)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+// Without uv, there's no way to tell which point is closest, so this is just the average.
+func (p VertexColorPattern) LocalPatternAt(point Tuple) Color {
+	return p.C1.Add(p.C2).Add(p.C3).Multiply(1.0 / 3)
+}
+
+func (p VertexColorPattern) LocalUVPatternAt(u, v float64) Color {
+	return p.C1.Multiply(1 - u - v).Add(p.C2.Multiply(u)).Add(p.C3.Multiply(v))
+}
+
+func (p VertexColorPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(VertexColorPattern)
+	return p.C1.IsEqualTo(p2Pattern.C1) && p.C2.IsEqualTo(p2Pattern.C2) && p.C3.IsEqualTo(p2Pattern.C3)
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p VertexColorPattern) localType() string {
+	return "VertexColorPattern"
+}
@@ -0,0 +1,48 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// VerticalGradientPattern blends from Bottom (y = -1) to Top (y = 1), and is
+// clamped beyond that. Used as a World.Background, it's a simple sky.
+type VerticalGradientPattern struct {
+	Bottom Color
+	Top    Color
+}
+
+func NewVerticalGradientPattern(bottom, top Color) *Pattern {
+	return NewPattern(VerticalGradientPattern{bottom, top})
+}
+
+func (p VerticalGradientPattern) String() string {
+	return fmt.Sprintf("VerticalGradientPattern( Bottom: %v Top: %v )", p.Bottom, p.Top)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p VerticalGradientPattern) LocalPatternAt(point Tuple) Color {
+	fraction := math.Min(1, math.Max(0, (point.Y+1)/2))
+	return p.Bottom.Add(p.Top.Subtract(p.Bottom).Multiply(fraction))
+}
+
+func (p VerticalGradientPattern) LocalUVPatternAt(u, v float64) Color {
+	return p.Bottom.Add(p.Top.Subtract(p.Bottom).Multiply(v))
+}
+
+func (p VerticalGradientPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(VerticalGradientPattern)
+	if !p.Bottom.IsEqualTo(p2Pattern.Bottom) || !p.Top.IsEqualTo(p2Pattern.Top) {
+		return false
+	}
+	return true
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p VerticalGradientPattern) localType() string {
+	return "VerticalGradientPattern"
+}
@@ -0,0 +1,68 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Volume is a homogeneous participating medium (e.g. smoke, murky water or a cloud)
+// that fills a closed shape (e.g. a sphere, cube or Csg), set with Material.Volume.
+// The shape's surface becomes invisible, and rays traveling through its inside are
+// attenuated and pick up the light that the medium scatters from the World's Lights.
+// Volumes don't cast shadows.
+type Volume struct {
+	Density float64 // how much light is absorbed or scattered, per unit of distance
+	Color   Color   // the fraction of light that's scattered rather than absorbed (i.e. albedo)
+	Steps   int     // how many points along a ray are sampled for scattered light
+}
+
+func NewVolume(density float64, color Color) *Volume {
+	return &Volume{Density: density, Color: color, Steps: 8}
+}
+
+func (v *Volume) String() string {
+	return fmt.Sprintf("Volume( Density: %v Color: %v Steps: %v )", v.Density, v.Color, v.Steps)
+}
+
+func (v *Volume) IsEqualTo(v2 *Volume) bool {
+	if v == nil || v2 == nil {
+		return v == v2
+	} else if v.Density != v2.Density {
+		return false
+	} else if !v.Color.IsEqualTo(v2.Color) {
+		return false
+	} else if v.Steps != v2.Steps {
+		return false
+	}
+	return true
+}
+
+// Returns the color of a ray that travels distance through the volume before it
+// reaches something with color behind.
+func (v *Volume) Apply(w *World, r *Ray, distance float64, behind Color) Color {
+	if v.Density <= 0 {
+		return behind
+	}
+	transmittance := math.Exp(-v.Density * distance)
+
+	// Beyond this distance, less than 0.1% of the light makes it through anyway.
+	distance = math.Min(distance, -math.Log(0.001)/v.Density)
+
+	// March along the ray, collecting the light that's scattered towards its origin
+	// at each step, and how much of that is absorbed on the way back.
+	scattered := Colors["Black"]
+	if v.Steps > 0 {
+		direction := r.Direction.Normalized()
+		stepSize := distance / float64(v.Steps)
+		for step := 0; step < v.Steps; step++ {
+			s := (float64(step) + 0.5) * stepSize
+			point := r.Origin.Add(direction.Multiply(s))
+			for _, light := range w.Lights {
+				weight := math.Exp(-v.Density*s) * v.Density * stepSize * light.IntensityAt(point, w)
+				scattered = scattered.Add(light.GetIntensity().Multiply(weight))
+			}
+		}
+	}
+
+	return behind.Multiply(transmittance).Add(scattered.MultiplyColor(v.Color))
+}
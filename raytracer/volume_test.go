@@ -0,0 +1,96 @@
+package raytracer
+
+import (
+	"math"
+	"testing"
+)
+
+func volumeTestWorld(volume *Volume) (*World, *Shape) {
+	w := NewWorld()
+	w.Background = NewSolidPattern(Colors["White"])
+	smoke := NewSphere()
+	smoke.Material.Volume = volume
+	w.Objects = []*Shape{smoke}
+	return w, smoke
+}
+
+func TestTheSurfaceOfAVolumeIsInvisible(t *testing.T) {
+	w, _ := volumeTestWorld(NewVolume(0, Colors["Black"]))
+	r := NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1))
+
+	assertEqualColor(t, Colors["White"], w.ColorAt(r, DefaultMaximumReflections))
+}
+
+func TestAVolumeAttenuatesRaysThatPassThroughIt(t *testing.T) {
+	w, _ := volumeTestWorld(NewVolume(0.5, Colors["Black"]))
+	r := NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1))
+
+	// The ray travels 2 units inside the sphere.
+	expected := Colors["White"].Multiply(math.Exp(-1))
+	assertEqualColor(t, expected, w.ColorAt(r, DefaultMaximumReflections))
+
+	// Rays that miss the sphere are left alone.
+	r = NewRay(NewPoint(0, 2, -5), NewVector(0, 0, 1))
+	assertEqualColor(t, Colors["White"], w.ColorAt(r, DefaultMaximumReflections))
+}
+
+func TestAVolumeScattersLight(t *testing.T) {
+	w, _ := volumeTestWorld(NewVolume(0.5, Colors["White"]))
+	w.Background = NewSolidPattern(Colors["Black"])
+	w.Lights = []*AreaLight{NewPointLight(NewPoint(0, 10, 0), Colors["White"])}
+	r := NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1))
+	actual := w.ColorAt(r, DefaultMaximumReflections)
+
+	// Without a light, it's black; with one, the medium glows a little.
+	assert(t, actual.Red > 0 && actual.Red < 1)
+	assertEqualColor(t, NewColor(actual.Red, actual.Red, actual.Red), actual)
+}
+
+func TestAVolumeDoesntCastShadows(t *testing.T) {
+	w, _ := volumeTestWorld(NewVolume(0.5, Colors["Black"]))
+	floor := NewPlane()
+	floor.SetTransform(NewTranslation(0, -1, 0))
+	w.Objects = append(w.Objects, floor)
+	w.Lights = []*AreaLight{NewPointLight(NewPoint(0, 10, 0), Colors["White"])}
+
+	assertEqualBool(t, false, w.IsShadowed(NewPoint(0, -1+EPSILON, 0), NewPoint(0, 10, 0)))
+}
+
+func TestVolumeBeforeAHit(t *testing.T) {
+	s1 := NewSphere()
+	s1.Material.Volume = NewVolume(1, Colors["White"])
+	s2 := NewSphere()
+	is := Intersections{
+		NewIntersection(1, s1),
+		NewIntersection(2, s2),
+		NewIntersection(3, s1),
+	}
+
+	assertEqualBool(t, true, is.VolumeBefore(is[0]) == nil)
+	assertEqualBool(t, true, is.VolumeBefore(is[1]) == s1.Material.Volume)
+	assertEqualBool(t, true, is.VolumeBefore(nil) == nil)
+}
+
+func TestVolumeBeforeAHitInOverlappingShapesThatShareAMaterial(t *testing.T) {
+	fog := DefaultMaterial()
+	fog.Volume = NewVolume(1, Colors["White"])
+	s1 := NewSphere()
+	s1.Material = fog
+	s2 := NewSphere()
+	s2.SetTransform(NewTranslation(0, 0, 1))
+	s2.Material = fog
+	s3 := NewSphere()
+	is := Intersections{
+		NewIntersection(1, s1),
+		NewIntersection(2, s2),
+		NewIntersection(2.5, s3),
+		NewIntersection(3, s1),
+		NewIntersection(3.5, s3),
+		NewIntersection(4, s2),
+		NewIntersection(4.5, s3),
+	}
+
+	assertEqualBool(t, true, is.VolumeBefore(is[2]) == fog.Volume) // inside both
+	assertEqualBool(t, true, is.VolumeBefore(is[4]) == fog.Volume) // inside s2 only
+	assertEqualBool(t, true, is.VolumeBefore(is[6]) == nil)
+}
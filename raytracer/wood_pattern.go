@@ -0,0 +1,52 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// WoodPattern is rings around the y axis (one per unit) that blend from A to B,
+// like the grain of a tree trunk, made irregular with Turbulence.
+type WoodPattern struct {
+	A          Color
+	B          Color
+	Turbulence float64 // how irregular the rings are
+	Octaves    int     // how detailed the irregularity is
+}
+
+func NewWoodPattern(a, b Color) *Pattern {
+	return NewPattern(WoodPattern{A: a, B: b, Turbulence: 0.5, Octaves: 3})
+}
+
+func (p WoodPattern) String() string {
+	return fmt.Sprintf("WoodPattern( A: %v B: %v Turbulence: %v Octaves: %v )", p.A, p.B, p.Turbulence, p.Octaves)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p WoodPattern) LocalPatternAt(point Tuple) Color {
+	distance := math.Sqrt(point.X*point.X + point.Z*point.Z)
+	distance += p.Turbulence * Turbulence(point.X, point.Y, point.Z, p.Octaves)
+	fraction := distance - math.Floor(distance)
+	return p.A.Add(p.B.Subtract(p.A).Multiply(fraction))
+}
+
+func (p WoodPattern) LocalUVPatternAt(u, v float64) Color {
+	return Colors["Black"]
+}
+
+func (p WoodPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(WoodPattern)
+	if !p.A.IsEqualTo(p2Pattern.A) || !p.B.IsEqualTo(p2Pattern.B) {
+		return false
+	}
+	return p.Turbulence == p2Pattern.Turbulence && p.Octaves == p2Pattern.Octaves
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p WoodPattern) localType() string {
+	return "WoodPattern"
+}
@@ -12,11 +12,40 @@ const DefaultMaximumReflections int = 4 // recommended on page 148
 type World struct {
 	Objects []*Shape
 	Lights  []*AreaLight
+
+	// What rays see when they miss every object (including reflected and refracted
+	// rays). It's looked up with the ray's direction as a point on the unit sphere,
+	// so it can be e.g. a SolidPattern, a VerticalGradientPattern, a CubeMapPattern,
+	// or an equirectangular image: NewTextureMapPattern(NewUVImagePattern(c), SphericalMap).
+	// Defaults to black when nil.
+	Background *Pattern
+
+	// Optionally lights the scene with an image of its surroundings, in addition to Lights.
+	EnvironmentLight *EnvironmentLight
+
+	// Optionally darkens the ambient term in corners and crevices.
+	AmbientOcclusion *AmbientOcclusion
+
+	// Optionally fills the whole world with haze.
+	Fog *Fog
+
+	// How many rays are averaged for reflections and refractions off of materials
+	// with Roughness (e.g. brushed metal or frosted glass), and the numbers used to
+	// spread them out (each pixel starts at a different place in them, see PixelJitter).
+	// NB: only the first rough surface that a ray hits spreads it out: after that, rough
+	// surfaces only trace one (randomly offset) ray each, so that the number of rays
+	// doesn't grow exponentially with each bounce.
+	GlossySamples int
+	GlossyJitter  *Sequence
 }
 
 // NewWorld instantiates a new World object.
 func NewWorld() *World {
-	return &World{}
+	jitter := NewRandomSequence(3, 4096)
+	return &World{
+		GlossySamples: 8,
+		GlossyJitter:  &jitter,
+	}
 }
 
 // DefaultWorld returns a new world with some default settings:
@@ -70,25 +99,35 @@ func (w *World) Intersect(r *Ray) Intersections {
 func (w *World) ShadeHit(c *Computation, remainingReflections int) Color {
 	color := NewColor(0, 0, 0)
 
+	if w.AmbientOcclusion != nil {
+		c.Occlusion = w.AmbientOcclusion.At(w, c)
+	}
+
 	for _, light := range w.Lights {
 		// isShadowed := w.IsShadowed(c.OverPoint, light)
 		intensity := light.IntensityAt(c.OverPoint, w)
-		surfaceColor := c.Object.Material.Lighting(c.Object, light, c.OverPoint, c.EyeV, c.NormalV, intensity)
-
-		reflectedColor := w.ReflectedColor(c, remainingReflections)
-		refractedColor := w.RefractedColor(c, remainingReflections)
-		if c.Object.Material.Reflective > 0 && c.Object.Material.Transparency > 0 {
-			reflectance := c.Schlick()
-			color = color.
-				Add(surfaceColor).
-				Add(reflectedColor.Multiply(reflectance)).
-				Add(refractedColor.Multiply(1 - reflectance))
-		} else {
-			color = color.
-				Add(surfaceColor).
-				Add(reflectedColor).
-				Add(refractedColor)
-		}
+		surfaceColor := c.Material.lightingAt(c, light, intensity)
+		color = color.Add(surfaceColor)
+	}
+
+	if w.EnvironmentLight != nil {
+		color = color.Add(w.EnvironmentLight.Lighting(w, c))
+	}
+
+	// NB: reflections and refractions are added once, not once per light (as the book
+	// does), so that they're as bright with any number of lights, including none (e.g.
+	// with only an EnvironmentLight).
+	reflectedColor := w.ReflectedColor(c, remainingReflections)
+	refractedColor := w.RefractedColor(c, remainingReflections)
+	if c.Material.Reflective > 0 && c.Material.Transparency > 0 {
+		reflectance := c.Schlick()
+		color = color.
+			Add(reflectedColor.Multiply(reflectance)).
+			Add(refractedColor.Multiply(1 - reflectance))
+	} else {
+		color = color.
+			Add(reflectedColor).
+			Add(refractedColor)
 	}
 
 	return color
@@ -97,43 +136,154 @@ func (w *World) ShadeHit(c *Computation, remainingReflections int) Color {
 func (w *World) ReflectedColor(c *Computation, remainingReflections int) Color {
 	if remainingReflections < 1 {
 		return Colors["Black"]
-	} else if c.Object.Material.Reflective == 0 {
+	} else if c.Material.Reflective == 0 {
 		return Colors["Black"]
 	} else {
-		reflectionRay := NewRay(c.OverPoint, c.ReflectV)
-		return w.ColorAt(reflectionRay, remainingReflections-1).
-			Multiply(c.Object.Material.Reflective)
+		// Rough surfaces spread the reflection out over several rays around the mirror direction.
+		directions := w.glossyDirections(c, c.ReflectV, c.NormalV, c.Material.Roughness)
+		color := Colors["Black"]
+		for _, direction := range directions {
+			reflectionRay := c.spawnRay(c.OverPoint, direction)
+			reflectionRay.Glossy = reflectionRay.Glossy || len(directions) > 1
+			color = color.Add(w.ColorAt(reflectionRay, remainingReflections-1))
+		}
+		return color.
+			Divide(float64(len(directions))).
+			Multiply(c.Material.Reflective)
+	}
+}
+
+// Returns the directions to trace for a reflection or refraction in direction, off
+// of a surface with the given roughness. Smooth surfaces (Roughness=0) only need
+// the one direction; otherwise it's GlossySamples directions (or just one, if the
+// ray was already spread out, see Ray.Glossy), randomly offset from it by up to the
+// roughness (like looking through a disk of that radius, 1 unit away). Perturbed
+// directions that would cross over to the other side of the surface (i.e. point
+// away from side) are left unperturbed instead. The offsets come from GlossyJitter,
+// or the hit's pixel's own copy of it (see PixelJitter).
+func (w *World) glossyDirections(c *Computation, direction, side Tuple, roughness float64) []Tuple {
+	jitter := c.Jitter.Sequence(w.GlossyJitter)
+	if roughness <= 0 || w.GlossySamples < 1 || jitter == nil {
+		return []Tuple{direction}
+	}
+
+	length := direction.Magnitude()
+	direction = direction.Normalized()
+	u, v := direction.OrthonormalBasis()
+
+	samples := w.GlossySamples
+	if c.Glossy {
+		samples = 1
 	}
+	directions := make([]Tuple, samples)
+	for i := range directions {
+		r := roughness * math.Sqrt(jitter.Next())
+		phi := 2 * math.Pi * jitter.Next()
+		perturbed := direction.
+			Add(u.Multiply(r * math.Cos(phi))).
+			Add(v.Multiply(r * math.Sin(phi))).
+			Normalized()
+		if perturbed.Dot(side) <= 0 {
+			perturbed = direction
+		}
+		directions[i] = perturbed.Multiply(length)
+	}
+	return directions
 }
 
 // ColorAt gets a ray's intersection in the world and returns that intersection's color.
 func (w *World) ColorAt(r *Ray, remainingReflections int) Color {
+	color, _ := w.colorAndDistanceAt(r, remainingReflections)
+	return color
+}
+
+// Same as ColorAt, but also returns how far the ray went before it hit something
+// (or +Inf if it didn't hit anything).
+func (w *World) colorAndDistanceAt(r *Ray, remainingReflections int) (Color, float64) {
 	var color Color
+	var distance float64
 
 	// 	Call intersect_world to find the intersections of the given ray with the given world.
 	is := w.Intersect(r)
 
 	// 2. Find the hit from the resulting intersections.
-	if hit := is.Hit(false); hit == nil {
+	hit := is.Hit(false)
+	if hit == nil {
 
-		// 3. Return the color black if there is no such intersection.
-		color = Colors["Black"]
+		// 3. Return the background color if there is no such intersection.
+		color = w.BackgroundAt(r.Direction)
+		distance = math.Inf(1)
 	} else {
 		// 4. Otherwise, precompute the necessary values with prepare_computations.
 		c := hit.PrepareComputations(r, is...)
 
-		// 5. Finally, call shade_hit to find the color at the hit.
-		color = w.ShadeHit(c, remainingReflections)
+		if c.Object.Material.Volume != nil {
+			// The surface of a volume is invisible, so just keep going through it.
+			throughRay := c.spawnRay(c.Point.Add(r.Direction.Normalized().Multiply(2*EPSILON)), r.Direction)
+			color = w.ColorAt(throughRay, remainingReflections)
+		} else {
+			// 5. Finally, call shade_hit to find the color at the hit.
+			color = w.ShadeHit(c, remainingReflections)
+		}
+		distance = hit.Time * r.Direction.Magnitude()
 	}
 
-	return color
+	// Account for any media between the ray's origin and the hit.
+	if volume := is.VolumeBefore(hit); volume != nil {
+		color = volume.Apply(w, r, distance, color)
+	}
+	if w.Fog != nil {
+		color = w.Fog.Apply(color, distance)
+	}
+
+	return color, distance
+}
+
+// AmbientOcclusionAt returns the ambient occlusion of a ray's hit as a gray color
+// (white = unoccluded), for rendering it as a separate image. Rays that miss are white.
+func (w *World) AmbientOcclusionAt(r *Ray) Color {
+	is := w.Intersect(r)
+	hit := is.Hit(false)
+	if hit == nil || w.AmbientOcclusion == nil {
+		return Colors["White"]
+	}
+	occlusion := w.AmbientOcclusion.At(w, hit.PrepareComputations(r, is...))
+	return NewColor(occlusion, occlusion, occlusion)
+}
+
+// BackgroundAt returns the color of the World's Background in the given direction.
+func (w *World) BackgroundAt(direction Tuple) Color {
+	if w.Background == nil {
+		return Colors["Black"]
+	}
+	d := direction.Normalized()
+	return w.Background.PatternAt(NewPoint(d.X, d.Y, d.Z))
 }
 
 func (w *World) RefractedColor(c *Computation, remaining int) Color { // remaining
-	if remaining == 0 || c.Object.Material.Transparency == 0 {
+	if remaining == 0 || c.Material.Transparency == 0 {
 		return Colors["Black"]
 	}
 
+	// With dispersion, each channel bends by a different amount, so trace a ray for each. From
+	// then on, each ray only carries its own channel (see Ray.Channel), so it isn't split up again.
+	if c.Channel == AllChannels && c.isDispersive() {
+		n1s, n2s := refractiveIndicesOf(c.N1Material), refractiveIndicesOf(c.N2Material)
+		colors := [3]Color{}
+		for idx := range colors {
+			channelComps := *c
+			channelComps.Channel = RedChannel + idx
+			channelComps.N1, channelComps.N2 = n1s[idx], n2s[idx]
+			colors[idx] = w.refractedColorFor(&channelComps, remaining)
+		}
+		return NewColor(colors[0].Red, colors[1].Green, colors[2].Blue)
+	}
+
+	return w.refractedColorFor(c, remaining)
+}
+
+// Returns the color of the refracted ray at the hit, with the computation's refractive indices.
+func (w *World) refractedColorFor(c *Computation, remaining int) Color {
 	// Check for Total Internal Reflection using Snell's Law (p 157)
 	// ... a phenomenon that occurs when light enters a new medium at a sufficiently acute angle, and the new medium has a lower refractive index than the old ...
 	nRatio := c.N1 / c.N2                                 // Find the ratio of first index of refraction to the second.
@@ -148,14 +298,57 @@ func (w *World) RefractedColor(c *Computation, remaining int) Color { // remaini
 
 	normalScaled := c.NormalV.Multiply(nRatio*cosI - cosT)
 	eyeScaled := c.EyeV.Multiply(nRatio)
-	direction := normalScaled.Subtract(eyeScaled)   // Compute the direction of the refracted ray
-	refractedRay := NewRay(c.UnderPoint, direction) // The refracted ray
+	direction := normalScaled.Subtract(eyeScaled) // Compute the direction of the refracted ray
+	// NB: UnderPoint is always just inside the object, so a ray that's leaving it starts from OverPoint instead.
+	origin := c.UnderPoint
+	if c.Inside {
+		origin = c.OverPoint
+	}
 
-	// Find the color of the refracted ray, making sure to multiply # by the transparency value to account for any opacity
-	color := w.ColorAt(refractedRay, remaining-1.0).
-		Multiply(c.Object.Material.Transparency)
+	// Rough materials (e.g. frosted glass) spread the refraction out over several rays.
+	directions := w.glossyDirections(c, direction, c.NormalV.Negate(), c.Material.Roughness)
+	color := Colors["Black"]
+	for _, direction := range directions {
+		refractedRay := c.spawnRay(origin, direction) // The refracted ray
+		refractedRay.Glossy = refractedRay.Glossy || len(directions) > 1
 
-	return color
+		// Find the color of the refracted ray, and how far it went
+		refractedColor, distance := w.colorAndDistanceAt(refractedRay, remaining-1.0)
+
+		// If the ray is entering the object, some of its light is absorbed on the way through (Beer-Lambert).
+		if !c.Inside {
+			refractedColor = absorb(c.Material.Absorption, distance, refractedColor)
+		}
+		color = color.Add(refractedColor)
+	}
+
+	// Make sure to multiply # by the transparency value to account for any opacity
+	return color.
+		Divide(float64(len(directions))).
+		Multiply(c.Material.Transparency)
+}
+
+// Returns the red, green and blue refractive indices of a material, or of vacuum if it's nil.
+func refractiveIndicesOf(m *Material) [3]float64 {
+	if m == nil {
+		return [3]float64{1, 1, 1}
+	}
+	return m.RefractiveIndices()
+}
+
+// Attenuates the color of a ray by how far it travels through a medium that absorbs
+// light, i.e. until it hits the next object. Rays that don't hit anything (e.g. through
+// an open surface, like a plane of water) are left as they are.
+func absorb(absorption Color, distance float64, color Color) Color {
+	if absorption.IsEqualTo(Colors["Black"]) || math.IsInf(distance, 1) {
+		return color
+	}
+
+	return NewColor(
+		color.Red*math.Exp(-absorption.Red*distance),
+		color.Green*math.Exp(-absorption.Green*distance),
+		color.Blue*math.Exp(-absorption.Blue*distance),
+	)
 }
 
 func (w *World) IsShadowed(p Tuple, lightPosition Tuple) bool {
@@ -112,6 +112,48 @@ func TestColorAtWhenRayMisses(t *testing.T) {
 	assertEqualColor(t, expected, actual)
 }
 
+func TestColorAtWhenRayMissesUsesTheBackground(t *testing.T) {
+	w := DefaultWorld()
+	w.Background = NewSolidPattern(NewColor(0.2, 0.4, 0.6))
+	r := NewRay(NewPoint(0, 0, -5), NewVector(0, 1, 0))
+	actual := w.ColorAt(r, DefaultMaximumReflections)
+
+	assertEqualColor(t, NewColor(0.2, 0.4, 0.6), actual)
+}
+
+func TestTheBackgroundIsLookedUpByDirection(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewVerticalGradientPattern(Colors["Black"], Colors["White"])
+	testCases := []struct {
+		direction Tuple
+		result    Color
+	}{
+		{NewVector(0, 1, 0), Colors["White"]},
+		{NewVector(0, -3, 0), Colors["Black"]},
+		{NewVector(0, 0, 2), NewColor(0.5, 0.5, 0.5)},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			r := NewRay(NewPoint(0, 0, 0), tc.direction)
+			assertEqualColor(t, tc.result, w.ColorAt(r, DefaultMaximumReflections))
+		})
+	}
+}
+
+func TestTheReflectedColorOfTheBackground(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewSolidPattern(NewColor(0, 0, 1))
+	shape := NewPlane()
+	shape.Material.Reflective = 0.5
+	w.Objects = []*Shape{shape}
+	r := NewRay(NewPoint(0, 1, -1), NewVector(0, -math.Sqrt(2)/2, math.Sqrt(2)/2))
+	i := NewIntersection(math.Sqrt(2), shape)
+	c := i.PrepareComputations(r)
+	actual := w.ReflectedColor(c, DefaultMaximumReflections)
+
+	assertEqualColor(t, NewColor(0, 0, 0.5), actual)
+}
+
 // Ray hits the outer sphere
 func TestColorAtWhenRayHitsOuterSphere(t *testing.T) {
 	w := DefaultWorld()
@@ -191,6 +233,35 @@ func TestTheRefractedColorWithARefractedRay(t *testing.T) {
 	assertEqualColor(t, NewColor(0, 0.99889, 0.04721), color)
 }
 
+func TestShadeHitAddsReflectionsOnceHoweverManyLightsThereAre(t *testing.T) {
+	testCases := []struct {
+		lights int
+	}{
+		{0},
+		{1},
+		{2},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			w := NewWorld()
+			w.Background = NewSolidPattern(NewColor(0.2, 0.4, 0.6))
+			for i := 0; i < tc.lights; i++ {
+				w.Lights = append(w.Lights, NewPointLight(NewPoint(float64(i), 10, 0), Colors["White"]))
+			}
+			floor := NewPlane()
+			w.Objects = []*Shape{floor}
+			r := NewRay(NewPoint(0, 1, -1), NewVector(0, -1, 1))
+
+			// The floor only reflects the background, which doesn't depend on the lights.
+			unreflected := w.ShadeHit(NewIntersection(1, floor).PrepareComputations(r), DefaultMaximumReflections)
+			floor.Material.Reflective = 0.5
+			actual := w.ShadeHit(NewIntersection(1, floor).PrepareComputations(r), DefaultMaximumReflections)
+
+			assertEqualColor(t, unreflected.Add(NewColor(0.1, 0.2, 0.3)), actual)
+		})
+	}
+}
+
 func TestShadeHitWithATransparentMaterial(t *testing.T) {
 	w := DefaultWorld()
 	floor := NewPlane()
@@ -253,6 +324,181 @@ func TestIsShadowTestsForOcclusionBetweenTwoPoints(t *testing.T) {
 	}
 }
 
+func TestRefractedColorIsAbsorbedByThickMaterials(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewSolidPattern(Colors["White"])
+	slab := NewCube()
+	slab.Material.Ambient = Colors["Black"]
+	slab.Material.Diffuse = Colors["Black"]
+	slab.Material.Specular = Colors["Black"]
+	slab.Material.Transparency = 1
+	slab.Material.RefractiveIndex = 1
+	slab.Material.Absorption = NewColor(0, 0.5, 1)
+	w.Objects = []*Shape{slab}
+
+	testCases := []struct {
+		thickness float64
+		result    Color
+	}{
+		{1, NewColor(1, math.Exp(-1), math.Exp(-2))},
+		{0.5, NewColor(1, math.Exp(-0.5), math.Exp(-1))},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			slab.SetTransform(NewScale(1, 1, tc.thickness))
+			r := NewRay(NewPoint(0, 0, -5), NewVector(0, 0, 1))
+
+			assertEqualColor(t, tc.result, w.ColorAt(r, DefaultMaximumReflections))
+		})
+	}
+}
+
+func TestRefractedColorThatEscapesIsNotAbsorbed(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewSolidPattern(Colors["White"])
+	water := NewPlane()
+	water.Material.Ambient = Colors["Black"]
+	water.Material.Diffuse = Colors["Black"]
+	water.Material.Specular = Colors["Black"]
+	water.Material.Transparency = 1
+	water.Material.RefractiveIndex = 1
+	water.Material.Absorption = NewColor(0, 0.5, 1)
+	w.Objects = []*Shape{water}
+	r := NewRay(NewPoint(0, 1, 0), NewVector(0, -1, 0))
+
+	// Nothing is below the water, so there's no distance to absorb light over.
+	assertEqualColor(t, Colors["White"], w.ColorAt(r, DefaultMaximumReflections))
+}
+
+func TestRefractedColorWithDispersion(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewVerticalGradientPattern(Colors["Black"], Colors["White"])
+	ball := NewGlassSphere()
+	ball.Material.Ambient = Colors["Black"]
+	ball.Material.Diffuse = Colors["Black"]
+	ball.Material.Specular = Colors["Black"]
+	w.Objects = []*Shape{ball}
+	r := NewRay(NewPoint(0, 0.5, -5), NewVector(0, 0, 1))
+
+	// Without dispersion, every channel bends the same way.
+	actual := w.ColorAt(r, DefaultMaximumReflections)
+	assertEqualColor(t, NewColor(actual.Red, actual.Red, actual.Red), actual)
+
+	// With it, they leave the ball in different directions and see different parts of the background.
+	ball.Material.Abbe = 20
+	actual = w.ColorAt(r, DefaultMaximumReflections)
+	assert(t, actual.Red != actual.Green && actual.Green != actual.Blue)
+
+	// Once a ray only carries one channel, it isn't split up again, so every channel bends its way.
+	r.Channel = GreenChannel
+	actual = w.ColorAt(r, DefaultMaximumReflections)
+	assertEqualColor(t, NewColor(actual.Green, actual.Green, actual.Green), actual)
+}
+
+func TestGlossyDirectionsForASmoothSurface(t *testing.T) {
+	w := NewWorld()
+	directions := w.glossyDirections(&Computation{}, NewVector(0, 1, 0), NewVector(0, 1, 0), 0)
+
+	assertEqualInt(t, 1, len(directions))
+	assertEqualTuple(t, NewVector(0, 1, 0), directions[0])
+}
+
+func TestGlossyDirectionsForARoughSurface(t *testing.T) {
+	w := NewWorld()
+	w.GlossySamples = 16
+	direction := NewVector(1, 1, 0).Normalized()
+	directions := w.glossyDirections(&Computation{}, direction, NewVector(0, 1, 0), 0.2)
+
+	assertEqualInt(t, 16, len(directions))
+	for _, d := range directions {
+		assertEqualFloat64(t, 1, d.Magnitude())
+		assert(t, d.Dot(NewVector(0, 1, 0)) > 0)
+		assert(t, d.Dot(direction) >= 1/math.Sqrt(1+0.2*0.2)-EPSILON) // within the lobe
+	}
+}
+
+func TestGlossyDirectionsForARayThatWasAlreadySpreadOut(t *testing.T) {
+	w := NewWorld()
+	w.GlossySamples = 16
+	direction := NewVector(1, 1, 0).Normalized()
+	directions := w.glossyDirections(&Computation{Glossy: true}, direction, NewVector(0, 1, 0), 0.2)
+
+	assertEqualInt(t, 1, len(directions))
+	assert(t, directions[0].Dot(direction) >= 1/math.Sqrt(1+0.2*0.2)-EPSILON)
+}
+
+func TestRaysReflectedOffARoughMaterialAreNotSpreadOutAgain(t *testing.T) {
+	testCases := []struct {
+		roughness float64
+		glossy    bool
+	}{
+		{0, false},
+		{0.2, true},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			w := NewWorld()
+			floor := NewPlane()
+			floor.Material.Reflective = 1
+			floor.Material.Roughness = tc.roughness
+			ts := NewTestShape()
+			w.Objects = []*Shape{floor, ts}
+			r := NewRay(NewPoint(0, 1, -1), NewVector(0, -1, 1))
+			c := NewIntersection(1, floor).PrepareComputations(r)
+			w.ReflectedColor(c, 1)
+
+			assertEqualBool(t, tc.glossy, ts.SavedRay.Glossy)
+		})
+	}
+}
+
+func TestReflectedColorForARoughMaterial(t *testing.T) {
+	render := func(roughness float64) Color {
+		w := NewWorld()
+		w.Background = NewVerticalGradientPattern(Colors["Black"], Colors["White"])
+		floor := NewPlane()
+		floor.Material.Reflective = 1
+		floor.Material.Roughness = roughness
+		w.Objects = []*Shape{floor}
+		r := NewRay(NewPoint(0, 1, -1), NewVector(0, -1, 1))
+		xs := Intersections{NewIntersection(1, floor)}
+		return w.ReflectedColor(xs[0].PrepareComputations(r, xs...), DefaultMaximumReflections)
+	}
+
+	mirror := render(0)
+	v := 0.5 + math.Sqrt(2)/4 // the gradient at 45° up
+	assertEqualColor(t, NewColor(v, v, v), mirror)
+
+	// Rough reflections are blurred over the background, but are the same every time.
+	glossy := render(0.5)
+	assert(t, !glossy.IsEqualTo(mirror))
+	assertEqualColor(t, glossy, render(0.5))
+}
+
+func TestReflectedColorWithAReflectivePattern(t *testing.T) {
+	w := NewWorld()
+	w.Background = NewSolidPattern(Colors["White"])
+	floor := NewPlane()
+	floor.Material.ReflectivePattern = NewCheckerPattern(Colors["Black"], NewColor(0.5, 0.5, 0.5))
+	w.Objects = []*Shape{floor}
+	testCases := []struct {
+		x      float64
+		result Color
+	}{
+		{0.5, Colors["Black"]},
+		{1.5, NewColor(0.5, 0.5, 0.5)},
+	}
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			r := NewRay(NewPoint(tc.x, 1, -1), NewVector(0, -1, 1))
+			xs := Intersections{NewIntersection(1, floor)}
+			c := xs[0].PrepareComputations(r, xs...)
+
+			assertEqualColor(t, tc.result, w.ReflectedColor(c, DefaultMaximumReflections))
+		})
+	}
+}
+
 /////////////
 // Benchmarks
 /////////////
@@ -0,0 +1,93 @@
+package raytracer
+
+import (
+	"fmt"
+	"math"
+)
+
+// The distances that a WorleyPattern can be colored by.
+const (
+	WorleyF1        = "f1"    // to the nearest feature point: round cells, like spots or pebbles
+	WorleyF2MinusF1 = "f2-f1" // between the two nearest feature points: thin lines where cells meet, like tiles or cracks
+)
+
+// WorleyPattern (i.e. cellular or Voronoi noise) scatters one random feature point in
+// each unit cube, and blends from A to B by the distance (see Metric) to them.
+type WorleyPattern struct {
+	A      Color
+	B      Color
+	Metric string // WorleyF1 or WorleyF2MinusF1
+}
+
+func NewWorleyPattern(a, b Color, metric string) *Pattern {
+	return NewPattern(WorleyPattern{A: a, B: b, Metric: metric})
+}
+
+func (p WorleyPattern) String() string {
+	return fmt.Sprintf("WorleyPattern( A: %v B: %v Metric: %v )", p.A, p.B, p.Metric)
+}
+
+// Returns the distances from the point to the nearest and second-nearest feature points.
+func worleyDistances(point Tuple) (float64, float64) {
+	cellX, cellY, cellZ := math.Floor(point.X), math.Floor(point.Y), math.Floor(point.Z)
+	f1, f2 := math.Inf(1), math.Inf(1)
+
+	// The nearest points are always in this cell or one of its neighbors.
+	for x := cellX - 1; x <= cellX+1; x++ {
+		for y := cellY - 1; y <= cellY+1; y++ {
+			for z := cellZ - 1; z <= cellZ+1; z++ {
+				distance := worleyFeaturePoint(x, y, z).Subtract(point).Magnitude()
+				if distance < f1 {
+					f1, f2 = distance, f1
+				} else if distance < f2 {
+					f2 = distance
+				}
+			}
+		}
+	}
+	return f1, f2
+}
+
+// Returns the feature point of the unit cube at the given (integer) coordinates. It's
+// pseudo-random, but always the same for the same cube (and repeats every 256 units).
+func worleyFeaturePoint(x, y, z float64) Tuple {
+	p := perlinPermutation
+	hash := p[p[p[int(x)&255]+int(y)&255]+int(z)&255]
+	return NewPoint(
+		x+float64(p[hash])/256,
+		y+float64(p[hash+1])/256,
+		z+float64(p[hash+2])/256,
+	)
+}
+
+/////////////////////////
+// PatternInterface methods
+/////////////////////////
+
+func (p WorleyPattern) LocalPatternAt(point Tuple) Color {
+	f1, f2 := worleyDistances(point)
+	distance := f1
+	if p.Metric == WorleyF2MinusF1 {
+		distance = f2 - f1
+	}
+	fraction := math.Min(distance, 1)
+	return p.A.Add(p.B.Subtract(p.A).Multiply(fraction))
+}
+
+func (p WorleyPattern) LocalUVPatternAt(u, v float64) Color {
+	return Colors["Black"]
+}
+
+func (p WorleyPattern) localIsEqualTo(p2 PatternInterface) bool {
+	p2Pattern := p2.(WorleyPattern)
+	if !p.A.IsEqualTo(p2Pattern.A) || !p.B.IsEqualTo(p2Pattern.B) {
+		return false
+	}
+	return p.Metric == p2Pattern.Metric
+}
+
+// Not returning reflect.TypeOf here because I suspect it
+// does the same thing under the hood and stores a string?
+func (p WorleyPattern) localType() string {
+	return "WorleyPattern"
+}
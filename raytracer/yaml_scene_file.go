@@ -3,6 +3,7 @@ package raytracer
 import (
 	"fmt"
 	"io/ioutil"
+	"path/filepath"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -33,6 +34,15 @@ type YamlInstruction struct {
 	Transform   yaml.Node
 	Material    yaml.Node
 	Value       yaml.Node
+	File        string  // for obj: the OBJ (or PLY or STL) file, relative to the YAML file
+	Smooth      bool    // for obj: generate normals for faces without them
+	Weighting   string  // for obj: how generated normals are averaged, "area" or "angle"
+	CreaseAngle float64 `yaml:"crease-angle"` // for obj: in radians, see ObjOptions
+	Mesh        bool    // for obj: put the faces in Meshes, see ObjOptions
+
+	// for obj: how its faces are divided into a BVH, see BVHOptions
+	BVH          string // "midpoint" or "sah"
+	BVHThreshold int    `yaml:"bvh-threshold"` // only groups with at least this many faces are divided
 }
 
 // NB: using pointers instead of values because values that
@@ -40,6 +50,9 @@ type YamlInstruction struct {
 // their zero values (e.g. 0.0), but we don't want to use
 // 0.0 if they're actually just missing.
 type YamlMaterial struct {
+	Model           *string
+	Metallic        *float64
+	Roughness       *float64
 	Color           [3]*float64
 	Diffuse         *float64
 	Ambient         *float64
@@ -48,6 +61,33 @@ type YamlMaterial struct {
 	Shininess       *float64
 	RefractiveIndex *float64 `yaml:"refractive-index"`
 	Transparency    *float64
+	Abbe            *float64
+	Absorption      [3]*float64
+	Pattern         *YamlPattern
+}
+
+// e.g.
+//
+//	pattern:
+//	  type: stripes
+//	  colors:
+//	    - [1, 1, 1]
+//	    - [0, 0, 0]
+//	  transform:
+//	    - [scale, 0.5, 0.5, 0.5]
+//
+// Instead of colors, patterns can have nested patterns (e.g. stripes of checkers),
+// and blends have a mode (average, multiply or lerp) with a weight or a mask.
+type YamlPattern struct {
+	Type      string
+	Colors    [][3]float64
+	Patterns  []YamlPattern
+	Transform yaml.Node
+	Metric    string       // for worley: f1 or f2-f1
+	Scale     *float64     // for perturbed: how far points are moved
+	Mode      string       // for blend: average, multiply or lerp
+	Weight    *float64     // for blend
+	Mask      *YamlPattern // for blend
 }
 
 // This returns a World as parsed from YAML, based on the format in the book.
@@ -105,7 +145,7 @@ func ParseYamlSceneFile(filename string) (YamlSceneFile, error) {
 					obj = NewCube()
 					obj.Label = "cube"
 				}
-				if m, err = decodeMaterial(ysf.MaterialDefs, *DefaultMaterial(), instruction.Material); err != nil {
+				if m, err = decodeMaterial(ysf.MaterialDefs, ysf.TransformationDefs, *DefaultMaterial(), instruction.Material); err != nil {
 					return ysf, err
 				}
 				obj.Material = &m
@@ -114,6 +154,54 @@ func ParseYamlSceneFile(filename string) (YamlSceneFile, error) {
 				}
 				obj.SetTransform(obj.Transform.Multiply(t))
 				ysf.World.Objects = append(ysf.World.Objects, obj)
+			case "obj":
+				// e.g.
+				//   - add: obj
+				//     file: teapot.obj
+				//     smooth: true
+				//     crease-angle: 1.0
+				//     mesh: true
+				//     bvh: sah
+				// The file can be an OBJ, PLY or STL file (see LoadMesh).
+				// With bvh, its groups are divided into a BVH (see DivideWithOptions), and
+				// its Meshes (which always have a BVH) are built with the same split.
+				// Without a material, faces keep the materials from the OBJ's MTL files.
+				path := instruction.File
+				if !filepath.IsAbs(path) {
+					path = filepath.Join(filepath.Dir(filename), path)
+				}
+				if instruction.BVH != "" && instruction.BVH != MidpointSplit && instruction.BVH != SAHSplit {
+					return ysf, fmt.Errorf("Unknown BVH split: %s\n", instruction.BVH)
+				}
+				options := ObjOptions{
+					SmoothNormals: instruction.Smooth,
+					Weighting:     instruction.Weighting,
+					CreaseAngle:   instruction.CreaseAngle,
+					Meshes:        instruction.Mesh,
+					BVH:           BVHOptions{Threshold: instruction.BVHThreshold, Split: instruction.BVH},
+				}
+				of, err := LoadMesh(path, options)
+				if err != nil {
+					return ysf, err
+				}
+				obj := of.ToGroup()
+				obj.Label = instruction.File
+				if instruction.BVH != "" {
+					obj.DivideWithOptions(options.BVH)
+				}
+				if !instruction.Material.IsZero() {
+					var m Material
+					if m, err = decodeMaterial(ysf.MaterialDefs, ysf.TransformationDefs, *DefaultMaterial(), instruction.Material); err != nil {
+						return ysf, err
+					}
+					obj.SetMaterialRecursively(&m)
+				}
+				t, err := decodeTransforms(ysf.TransformationDefs, instruction.Transform)
+				if err != nil {
+					return ysf, err
+				}
+				obj.SetTransform(obj.Transform.Multiply(t))
+				ysf.World.Objects = append(ysf.World.Objects, obj)
 			default:
 				return ysf, fmt.Errorf("Unknown instruction: %s\n", instruction.Add)
 			}
@@ -121,14 +209,13 @@ func ParseYamlSceneFile(filename string) (YamlSceneFile, error) {
 			var m Material
 			var t Matrix
 			if strings.HasSuffix(instruction.Define, "-material") {
-				// TODO: patterns
 				if instruction.Extend != "" {
 					m = ysf.MaterialDefs[instruction.Extend]
 				} else {
 					m = *DefaultMaterial()
 				}
 				m.Label = instruction.Define
-				if m, err = decodeMaterial(ysf.MaterialDefs, m, instruction.Value); err != nil {
+				if m, err = decodeMaterial(ysf.MaterialDefs, ysf.TransformationDefs, m, instruction.Value); err != nil {
 					return ysf, err
 				}
 				ysf.MaterialDefs[instruction.Define] = m
@@ -196,7 +283,7 @@ func decodeYamlNodeArray(n yaml.Node) ([]yaml.Node, error) {
 	return val, nil
 }
 
-func decodeMaterial(defs map[string]Material, m Material, n yaml.Node) (Material, error) {
+func decodeMaterial(defs map[string]Material, transformDefs map[string]Matrix, m Material, n yaml.Node) (Material, error) {
 	// 1: try to decode as definition string
 	if defKey, err := decodeString(n); err == nil {
 		if def, ok := defs[defKey]; ok {
@@ -210,6 +297,20 @@ func decodeMaterial(defs map[string]Material, m Material, n yaml.Node) (Material
 		if err := n.Decode(&v); err != nil {
 			return m, err
 		}
+		if v.Model != nil {
+			switch *v.Model {
+			case PhongModel, PBRModel:
+				m.Model = *v.Model
+			default:
+				return m, fmt.Errorf("Unknown material model: %s\n", *v.Model)
+			}
+		}
+		if v.Metallic != nil {
+			m.Metallic = *v.Metallic
+		}
+		if v.Roughness != nil {
+			m.Roughness = *v.Roughness
+		}
 		if v.Color[0] != nil {
 			r, g, b, err := decodeFloat64Tuple(*v.Color[0], *v.Color[1], *v.Color[2])
 			if err != nil {
@@ -238,10 +339,129 @@ func decodeMaterial(defs map[string]Material, m Material, n yaml.Node) (Material
 		if v.RefractiveIndex != nil {
 			m.RefractiveIndex = *v.RefractiveIndex
 		}
+		if v.Pattern != nil {
+			p, err := decodePattern(transformDefs, *v.Pattern)
+			if err != nil {
+				return m, err
+			}
+			m.Pattern = p
+		}
+		if v.Abbe != nil {
+			m.Abbe = *v.Abbe
+		}
+		if v.Absorption[0] != nil {
+			r, g, b, err := decodeFloat64Tuple(*v.Absorption[0], *v.Absorption[1], *v.Absorption[2])
+			if err != nil {
+				return m, err
+			}
+			m.Absorption = NewColor(r, g, b)
+		}
 	}
 	return m, nil
 }
 
+func decodePattern(transformDefs map[string]Matrix, v YamlPattern) (*Pattern, error) {
+	var p *Pattern
+
+	colors := make([]Color, len(v.Colors))
+	for i, c := range v.Colors {
+		colors[i] = NewColor(c[0], c[1], c[2])
+	}
+	patterns := make([]*Pattern, len(v.Patterns))
+	for i, nested := range v.Patterns {
+		var err error
+		if patterns[i], err = decodePattern(transformDefs, nested); err != nil {
+			return nil, err
+		}
+	}
+
+	switch v.Type {
+	case "solid":
+		if len(colors) != 1 {
+			return nil, fmt.Errorf("Pattern '%s' needs 1 color\n", v.Type)
+		}
+		p = NewSolidPattern(colors[0])
+	case "stripes", "gradient", "rings", "checkers":
+		constructors := map[string]func(a, b Color) *Pattern{
+			"stripes":  NewStripePattern,
+			"gradient": NewGradientPattern,
+			"rings":    NewRingPattern,
+			"checkers": NewCheckerPattern,
+		}
+		nestedConstructors := map[string]func(a, b *Pattern) *Pattern{
+			"stripes":  NewStripePatternFromPatterns,
+			"gradient": NewGradientPatternFromPatterns,
+			"rings":    NewRingPatternFromPatterns,
+			"checkers": NewCheckerPatternFromPatterns,
+		}
+		if len(patterns) == 2 {
+			p = nestedConstructors[v.Type](patterns[0], patterns[1])
+		} else if len(colors) == 2 {
+			p = constructors[v.Type](colors[0], colors[1])
+		} else {
+			return nil, fmt.Errorf("Pattern '%s' needs 2 colors or 2 patterns\n", v.Type)
+		}
+	case "vertical-gradient", "radial-gradient", "marble", "wood", "worley":
+		if len(colors) != 2 {
+			return nil, fmt.Errorf("Pattern '%s' needs 2 colors\n", v.Type)
+		}
+		switch v.Type {
+		case "vertical-gradient":
+			p = NewVerticalGradientPattern(colors[0], colors[1])
+		case "radial-gradient":
+			p = NewRadialGradientPattern(colors[0], colors[1])
+		case "marble":
+			p = NewMarblePattern(colors[0], colors[1])
+		case "wood":
+			p = NewWoodPattern(colors[0], colors[1])
+		case "worley":
+			switch v.Metric {
+			case "", WorleyF1:
+				p = NewWorleyPattern(colors[0], colors[1], WorleyF1)
+			case WorleyF2MinusF1:
+				p = NewWorleyPattern(colors[0], colors[1], WorleyF2MinusF1)
+			default:
+				return nil, fmt.Errorf("Unknown worley metric: %s\n", v.Metric)
+			}
+		}
+	case "perturbed":
+		if len(patterns) != 1 || v.Scale == nil {
+			return nil, fmt.Errorf("Pattern '%s' needs 1 pattern and a scale\n", v.Type)
+		}
+		p = NewPerturbedPattern(patterns[0], *v.Scale)
+	case "blend":
+		if len(patterns) != 2 {
+			return nil, fmt.Errorf("Pattern '%s' needs 2 patterns\n", v.Type)
+		}
+		switch {
+		case v.Mask != nil:
+			mask, err := decodePattern(transformDefs, *v.Mask)
+			if err != nil {
+				return nil, err
+			}
+			p = NewMaskedPattern(patterns[0], patterns[1], mask)
+		case v.Weight != nil:
+			p = NewLerpedPattern(patterns[0], patterns[1], *v.Weight)
+		case v.Mode == "":
+			p = NewBlendedPattern(patterns[0], patterns[1], BlendAverage)
+		case v.Mode == BlendAverage || v.Mode == BlendMultiply || v.Mode == BlendLerp:
+			p = NewBlendedPattern(patterns[0], patterns[1], v.Mode)
+		default:
+			return nil, fmt.Errorf("Unknown blend mode: %s\n", v.Mode)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown pattern type: %s\n", v.Type)
+	}
+
+	t, err := decodeTransforms(transformDefs, v.Transform)
+	if err != nil {
+		return nil, err
+	}
+	p.SetTransform(t)
+
+	return p, nil
+}
+
 func decodeTransforms(defs map[string]Matrix, n yaml.Node) (Matrix, error) {
 	t := IdentityMatrix()
 	nodeArray, err := decodeYamlNodeArray(n)
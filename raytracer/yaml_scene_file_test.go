@@ -0,0 +1,269 @@
+package raytracer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+// Writes the YAML to a file and parses it, e.g. to test one instruction at a time.
+func parseTestYamlSceneFile(t *testing.T, contents string) (YamlSceneFile, error) {
+	filename := filepath.Join(t.TempDir(), "scene.yml")
+	if err := ioutil.WriteFile(filename, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ParseYamlSceneFile(filename)
+}
+
+// Returns the absolute path of a file in files/, since YAML files are in a temp dir.
+func testFilePath(t *testing.T, name string) string {
+	path, err := filepath.Abs(filepath.Join("files", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParsingAYamlObjWithABVH(t *testing.T) {
+	ysf, err := parseTestYamlSceneFile(t, fmt.Sprintf(`
+- add: obj
+  file: %s
+  bvh: sah
+  bvh-threshold: 4
+`, testFilePath(t, "utah_teapot_lowres.obj")))
+	assertNil(t, err)
+	assertEqualInt(t, 1, len(ysf.World.Objects))
+
+	stats := ysf.World.Objects[0].BVHStats()
+	assert(t, stats.Groups > 1)
+	assertEqualInt(t, 0, stats.InteriorShapes)
+}
+
+func TestParsingAYamlObjWithMeshesBuiltWithTheSurfaceAreaHeuristic(t *testing.T) {
+	path := testFilePath(t, "utah_teapot_lowres.obj")
+	ysf, err := parseTestYamlSceneFile(t, fmt.Sprintf(`
+- add: obj
+  file: %s
+  mesh: true
+  bvh: sah
+`, path))
+	assertNil(t, err)
+
+	of, err := LoadMesh(path, ObjOptions{Meshes: true, BVH: BVHOptions{Split: SAHSplit}})
+	assertNil(t, err)
+	expected := findMesh(of.ToGroup()).BVHStats()
+	actual := findMesh(ysf.World.Objects[0]).BVHStats()
+	assertEqualString(t, expected.String(), actual.String())
+}
+
+func TestParsingAYamlObjWithAnUnknownBVHSplit(t *testing.T) {
+	_, err := parseTestYamlSceneFile(t, fmt.Sprintf(`
+- add: obj
+  file: %s
+  bvh: octree
+`, testFilePath(t, "triangles.obj")))
+	assertEqualString(t, "Unknown BVH split: octree\n", err.Error())
+}
+
+func TestParsingAYamlPBRMaterial(t *testing.T) {
+	ysf, err := parseTestYamlSceneFile(t, `
+- define: gold-material
+  value:
+    model: pbr
+    color: [1, 0.8, 0.3]
+    metallic: 1
+    roughness: 0.25
+- add: sphere
+  material: gold-material
+- add: sphere
+  material:
+    model: pbr
+    roughness: 0.5
+`)
+	assertNil(t, err)
+	assertEqualInt(t, 2, len(ysf.World.Objects))
+
+	gold := ysf.World.Objects[0].Material
+	assertEqualString(t, PBRModel, gold.Model)
+	assertEqualColor(t, NewColor(1, 0.8, 0.3), gold.Color)
+	assertEqualFloat64(t, 1, gold.Metallic)
+	assertEqualFloat64(t, 0.25, gold.Roughness)
+
+	dielectric := ysf.World.Objects[1].Material
+	assertEqualString(t, PBRModel, dielectric.Model)
+	assertEqualFloat64(t, 0, dielectric.Metallic)
+	assertEqualFloat64(t, 0.5, dielectric.Roughness)
+}
+
+func TestParsingAYamlMaterialWithAnUnknownModel(t *testing.T) {
+	_, err := parseTestYamlSceneFile(t, `
+- add: sphere
+  material:
+    model: lambert
+`)
+	assertEqualString(t, "Unknown material model: lambert\n", err.Error())
+}
+
+func TestParsingYamlPatterns(t *testing.T) {
+	nestedCheckers := NewCheckerPattern(Colors["White"], Colors["Black"])
+	nestedCheckers.SetTransform(NewScale(0.5, 0.5, 0.5))
+	nested := NewStripePatternFromPatterns(nestedCheckers, NewSolidPattern(NewColor(0, 0, 1)))
+	nested.SetTransform(NewRotateY(math.Pi / 2))
+
+	var testCases = []struct {
+		Yaml     string
+		Expected *Pattern
+	}{
+		{`
+      type: stripes
+      patterns:
+        - type: checkers
+          colors:
+            - [1, 1, 1]
+            - [0, 0, 0]
+          transform:
+            - [scale, 0.5, 0.5, 0.5]
+        - type: solid
+          colors:
+            - [0, 0, 1]
+      transform:
+        - [rotate-y, 1.5707963267948966]`, nested},
+		{`
+      type: blend
+      mode: multiply
+      patterns:
+        - type: solid
+          colors: [[1, 0, 0]]
+        - type: rings
+          colors: [[1, 1, 1], [0, 0, 0]]`, NewBlendedPattern(NewSolidPattern(NewColor(1, 0, 0)), NewRingPattern(Colors["White"], Colors["Black"]), BlendMultiply)},
+		{`
+      type: blend
+      patterns:
+        - type: solid
+          colors: [[1, 0, 0]]
+        - type: solid
+          colors: [[0, 1, 0]]`, NewBlendedPattern(NewSolidPattern(NewColor(1, 0, 0)), NewSolidPattern(NewColor(0, 1, 0)), BlendAverage)},
+		{`
+      type: blend
+      weight: 0.25
+      patterns:
+        - type: solid
+          colors: [[1, 0, 0]]
+        - type: solid
+          colors: [[0, 1, 0]]`, NewLerpedPattern(NewSolidPattern(NewColor(1, 0, 0)), NewSolidPattern(NewColor(0, 1, 0)), 0.25)},
+		{`
+      type: blend
+      mask:
+        type: gradient
+        colors: [[0, 0, 0], [1, 1, 1]]
+      patterns:
+        - type: solid
+          colors: [[1, 0, 0]]
+        - type: solid
+          colors: [[0, 1, 0]]`, NewMaskedPattern(NewSolidPattern(NewColor(1, 0, 0)), NewSolidPattern(NewColor(0, 1, 0)), NewGradientPattern(Colors["Black"], Colors["White"]))},
+		{`
+      type: worley
+      colors: [[1, 1, 1], [0, 0, 0]]`, NewWorleyPattern(Colors["White"], Colors["Black"], WorleyF1)},
+		{`
+      type: worley
+      metric: f2-f1
+      colors: [[1, 1, 1], [0, 0, 0]]`, NewWorleyPattern(Colors["White"], Colors["Black"], WorleyF2MinusF1)},
+		{`
+      type: radial-gradient
+      colors: [[1, 0, 0], [0, 0, 1]]`, NewRadialGradientPattern(NewColor(1, 0, 0), NewColor(0, 0, 1))},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			ysf, err := parseTestYamlSceneFile(t, `
+- add: sphere
+  material:
+    pattern:`+tc.Yaml+"\n")
+			assertNil(t, err)
+			pattern := ysf.World.Objects[0].Material.Pattern
+			if !tc.Expected.IsEqualTo(pattern) {
+				t.Errorf("Expected %v to be %v", pattern, tc.Expected)
+			}
+		})
+	}
+}
+
+func TestParsingInvalidYamlPatterns(t *testing.T) {
+	var testCases = []struct {
+		Yaml     string
+		Expected string
+	}{
+		{`
+      type: stripes
+      colors: [[1, 1, 1]]`, "Pattern 'stripes' needs 2 colors or 2 patterns\n"},
+		{`
+      type: blend
+      mode: screen
+      patterns: [{type: solid, colors: [[1, 1, 1]]}, {type: solid, colors: [[0, 0, 0]]}]`, "Unknown blend mode: screen\n"},
+		{`
+      type: worley
+      metric: f3
+      colors: [[1, 1, 1], [0, 0, 0]]`, "Unknown worley metric: f3\n"},
+		{`
+      type: plaid`, "Unknown pattern type: plaid\n"},
+	}
+
+	for idx, tc := range testCases {
+		t.Run(fmt.Sprintf("testCases[%d]", idx), func(t *testing.T) {
+			_, err := parseTestYamlSceneFile(t, `
+- add: sphere
+  material:
+    pattern:`+tc.Yaml+"\n")
+			assertEqualString(t, tc.Expected, err.Error())
+		})
+	}
+}
+
+func TestParsingAYamlObjWithGeneratedNormals(t *testing.T) {
+	// The same faces as TestParseObjWithOptionsGeneratesNormals, in a file next to the YAML.
+	dir := t.TempDir()
+	obj := `v 0 0 0
+v 1 0 0
+v 1 0 1
+v 0 1 0
+f 1 2 3
+f 1 4 2`
+	if err := ioutil.WriteFile(filepath.Join(dir, "faces.obj"), []byte(obj), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		yaml   string
+		normal Tuple // at the first vertex of the first face
+	}{
+		{"smooth: true", NewVector(0, math.Sqrt2/2, math.Sqrt2/2)},
+		{"smooth: true\n  weighting: angle", NewVector(0, 1/math.Sqrt(5), 2/math.Sqrt(5))},
+		{"smooth: true\n  crease-angle: 0.7853981633974483", NewVector(0, 1, 0)},
+		{"smooth: true\n  crease-angle: 1.5707963267948966", NewVector(0, math.Sqrt2/2, math.Sqrt2/2)},
+	}
+	for idx, tc := range testCases {
+		for _, mesh := range []bool{false, true} {
+			t.Run(fmt.Sprintf("testCases[%d] (mesh: %v)", idx, mesh), func(t *testing.T) {
+				filename := filepath.Join(dir, "scene.yml")
+				scene := fmt.Sprintf("- add: obj\n  file: faces.obj\n  mesh: %v\n  %s\n", mesh, tc.yaml)
+				if err := ioutil.WriteFile(filename, []byte(scene), 0644); err != nil {
+					t.Fatal(err)
+				}
+				ysf, err := ParseYamlSceneFile(filename)
+				assertNil(t, err)
+
+				var normal Tuple
+				if mesh {
+					m := findMesh(ysf.World.Objects[0]).LocalShape.(*Mesh)
+					normal = m.Normals[m.NormalIndices[0][0]]
+				} else {
+					faces := ysf.World.Objects[0].LocalShape.(Group).Children[0]
+					normal = faces.LocalShape.(Group).Children[0].LocalShape.(*SmoothTriangle).N1
+				}
+				assertEqualTuple(t, tc.normal, normal)
+			})
+		}
+	}
+}